@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/liffiton/repoman/internal/api"
+	"github.com/liffiton/repoman/internal/git"
+	"github.com/liffiton/repoman/internal/ui"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// defaultArchiveConcurrency is the number of concurrent archives when not configured.
+const defaultArchiveConcurrency = 6
+
+var (
+	archiveOut    string
+	archiveRef    string
+	archiveMirror bool
+)
+
+func init() {
+	archiveCmd.Flags().StringVarP(&archiveOut, "out", "o", "", "Directory to write <repo>.zip files to (created if needed)")
+	archiveCmd.Flags().StringVar(&archiveRef, "ref", "", "Tag, branch, or commit to archive (default: HEAD)")
+	archiveCmd.Flags().BoolVar(&archiveMirror, "mirror", false, "Keep a mirror of each repository with all of its refs in <repo>.git instead of zipping clones")
+	archiveCmd.Flags().BoolVar(&useHTTP, "http", false, "Use HTTP instead of SSH to mirror repositories (with --mirror)")
+	archiveCmd.MarkFlagsMutuallyExclusive("mirror", "ref")
+	addRateLimitFlag(archiveCmd)
+	archiveCmd.Flags().IntVar(&concurrency, "concurrency", defaultArchiveConcurrency, "Number of repositories to archive concurrently")
+	_ = archiveCmd.MarkFlagRequired("out")
+	_ = archiveCmd.MarkFlagDirname("out")
+	addRefreshFlag(archiveCmd)
+	rootCmd.AddCommand(archiveCmd)
+}
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive --out DIR",
+	Short: "Export a zip snapshot of each cloned repository",
+	Long: `Export a zip snapshot of each cloned repository.
+
+Writes <repo>.zip to the output directory for every cloned repository, containing its
+tracked files at HEAD (or --ref) without the .git directory. Uncommitted changes are
+not included.
+
+With --mirror, each repository is instead mirrored from the Git host into <repo>.git in
+the output directory: a bare repository with all of its branches and tags, but no working
+tree. Repositories need not be cloned first. Running it again updates the mirrors,
+removing branches and tags that were deleted on the host.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workers, err := resolveConcurrency(cmd, defaultArchiveConcurrency)
+		if err != nil {
+			return err
+		}
+
+		if rateLimit != "" && !archiveMirror {
+			return errors.New("--rate-limit only applies with --mirror")
+		}
+
+		ctx, err := loadWorkspaceContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+		applyUseHTTP(cmd, ctx.Wcfg)
+
+		// The workspace context may change the working directory, so resolve --out
+		// against the directory the command was run from.
+		outDir := archiveOut
+		if !filepath.IsAbs(outDir) {
+			outDir = filepath.Join(ctx.OrigDir, outDir)
+		}
+
+		ui.PrintHeader(fmt.Sprintf("Archiving repositories for %s", pterm.Bold.Sprintf("%s - %s", ctx.Wcfg.CourseName, ctx.Wcfg.AssignmentName)))
+		if ctx.OrigDir != ctx.Wcfg.Root {
+			ui.Dim.Printf("Workspace: %s\n", ctx.Wcfg.Root)
+		}
+		if archiveMirror {
+			workers, err := applyRateLimit(workers)
+			if err != nil {
+				return err
+			}
+			pterm.Println()
+			return mirrorRepos(cmd.Context(), ctx.Repos, outDir, workers)
+		}
+		pterm.Println()
+
+		// Only repositories that have been cloned can be archived.
+		var gitRepos []git.RepoInfo
+		for _, r := range ctx.Repos {
+			if _, err := os.Stat(r.Name); err == nil {
+				gitRepos = append(gitRepos, git.RepoInfo{
+					Name: r.Name,
+					Path: r.Name,
+				})
+			}
+		}
+
+		if len(gitRepos) == 0 {
+			fmt.Println("No cloned repositories found. Run 'repoman sync' first.")
+			return nil
+		}
+		if skipped := len(ctx.Repos) - len(gitRepos); skipped > 0 {
+			ui.Warning.Printf("Skipping %d repositories that have not been cloned.\n", skipped)
+		}
+
+		if err := os.MkdirAll(outDir, 0o750); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+
+		bar := ui.StartProgress(len(gitRepos), "Archiving")
+
+		manager := git.NewManager(workers)
+		results := manager.ArchiveAllCtx(cmd.Context(), gitRepos, archiveRef, outDir, func() {
+			bar.Increment()
+		})
+
+		fmt.Println() // New line after progress bar
+
+		successCount := 0
+		var total int64
+		for _, r := range results {
+			if r.Error != nil {
+				ui.Error.Printf("Error archiving %s: %v\n", r.Name, r.Error)
+				continue
+			}
+			successCount++
+			total += r.Size
+		}
+
+		fmt.Println(ui.Success.Sprint("Archive complete. ") + fmt.Sprintf("%d/%d repositories archived to %s, %s written.", successCount, len(gitRepos), outDir, formatBytes(total)))
+
+		return nil
+	},
+}
+
+// mirrorRepos mirrors each of repos into "<name>.git" in outDir, creating or updating the
+// mirrors.
+func mirrorRepos(ctx context.Context, repos []api.Repo, outDir string, workers int) error {
+	if len(repos) == 0 {
+		fmt.Println("No repositories found for this assignment.")
+		return nil
+	}
+	if err := os.MkdirAll(outDir, 0o750); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	var gitRepos []git.RepoInfo
+	for _, r := range repos {
+		gitRepos = append(gitRepos, git.RepoInfo{
+			Name:    r.Name,
+			URL:     r.URL,
+			Path:    filepath.Join(outDir, r.Name+".git"),
+			UseHTTP: useHTTP,
+			Mirror:  true,
+		})
+	}
+
+	bar := ui.StartProgress(len(gitRepos), "Mirroring")
+
+	manager := git.NewManager(workers)
+	errs, _ := manager.SyncAllTimedCtx(ctx, gitRepos, func(done git.RepoInfo, err error) {
+		bar.IncrementItem(done.Name, err != nil)
+	})
+
+	fmt.Println() // New line after progress bar
+
+	if ctx.Err() != nil {
+		return reportSyncCancelled(repos, errs)
+	}
+
+	successCount := 0
+	for i, err := range errs {
+		if err != nil {
+			ui.Error.Printf("Error mirroring %s: %v\n", gitRepos[i].Name, err)
+			continue
+		}
+		successCount++
+	}
+
+	fmt.Println(ui.Success.Sprint("Mirror complete. ") + fmt.Sprintf("%d/%d repositories mirrored to %s.", successCount, len(gitRepos), outDir))
+	if successCount < len(gitRepos) {
+		return fmt.Errorf("%d repositories failed to mirror", len(gitRepos)-successCount)
+	}
+	return nil
+}
@@ -2,16 +2,32 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
 	"strings"
 
+	"github.com/liffiton/repoman/internal/api"
+	"github.com/liffiton/repoman/internal/config"
 	"github.com/liffiton/repoman/internal/ui"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 )
 
+var (
+	authNoVerify    bool
+	authLogout      bool
+	authGitHubToken bool
+	authGitToken    bool
+)
+
 func init() {
+	authCmd.Flags().BoolVar(&authNoVerify, "no-verify", false, "Save the settings without checking them against the server")
+	authCmd.Flags().BoolVar(&authLogout, "logout", false, "Remove the stored API key from the keyring and config file")
+	authCmd.Flags().BoolVar(&authGitHubToken, "github-token", false, "Set the GitHub token used to check for updates instead of the API key")
+	authCmd.Flags().BoolVar(&authGitToken, "git-token", false, "Set the access token used for git over HTTPS instead of the API key")
+	authCmd.MarkFlagsMutuallyExclusive("no-verify", "logout", "github-token", "git-token")
 	rootCmd.AddCommand(authCmd)
 }
 
@@ -19,20 +35,24 @@ var authCmd = &cobra.Command{
 	Use:   "auth",
 	Short: "Configure authentication for the Repoman service",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if authLogout {
+			return logout()
+		}
+		if authGitHubToken {
+			return saveToken("GitHub token", &cfg.GitHubToken, config.GitHubTokenEnvVar)
+		}
+		if authGitToken {
+			return saveToken("git access token", &cfg.GitToken, config.GitTokenEnvVar)
+		}
+
 		ui.PrintHeader("Configure Authentication")
 		pterm.Println()
 
-		var apiKey string
 		ui.Dim.Println("Your API key can be found in the Settings page of the Class Repo Manager web application.")
-		apiKey, err := pterm.DefaultInteractiveTextInput.
-			WithDefaultText("Enter API Key").
-			WithMask("*").
-			Show()
+		apiKey, err := readAPIKey()
 		if err != nil {
-			return fmt.Errorf("failed to read API key: %w", err)
+			return err
 		}
-		apiKey = strings.TrimSpace(apiKey)
-
 		if apiKey == "" {
 			return errors.New("API key cannot be empty")
 		}
@@ -45,6 +65,36 @@ var authCmd = &cobra.Command{
 			return fmt.Errorf("failed to read Base URL: %w", err)
 		}
 		baseURL = strings.TrimSpace(baseURL)
+		if baseURL != "" {
+			if err := config.ValidateBaseURL(baseURL); err != nil {
+				return err
+			}
+		}
+
+		if !authNoVerify {
+			checkURL := baseURL
+			if checkURL == "" {
+				checkURL = cfg.GetBaseURL()
+			}
+			err := verifyAuth(cmd.Context(), checkURL, apiKey)
+			// A rejected key is most likely mistyped, so ask for it again.
+			for errors.Is(err, api.ErrUnauthorized) {
+				ui.Error.Println("The server rejected the API key. Enter it again, or leave it empty to stop.")
+				if apiKey, err = readAPIKey(); err != nil {
+					return err
+				}
+				if apiKey == "" {
+					return errors.New("authentication not saved")
+				}
+				err = verifyAuth(cmd.Context(), checkURL, apiKey)
+			}
+			if err != nil {
+				ui.Error.Printf("Could not verify the settings: %v\n", err)
+				if !confirm("Save them anyway?") {
+					return errors.New("authentication not saved")
+				}
+			}
+		}
 
 		cfg.APIKey = apiKey
 		if baseURL != "" {
@@ -58,12 +108,20 @@ var authCmd = &cobra.Command{
 
 		ui.Success.Println("\nAuthentication configured successfully!")
 
+		if cfg.Profile != config.DefaultProfile {
+			ui.Info.Printf("Profile: %s\n", cfg.Profile)
+		}
+
 		if result.KeyringUsed {
 			ui.Info.Println("API Key: Saved securely in the system keyring.")
 		} else {
 			ui.Info.Printf("API Key: Saved in the config file (%s) because the system keyring was unavailable.\n", result.ConfigPath)
 		}
 
+		if os.Getenv(config.APIKeyEnvVar) != "" || os.Getenv(config.BaseURLEnvVar) != "" {
+			ui.Warning.Printf("%s/%s are set in the environment and will override the saved settings.\n", config.APIKeyEnvVar, config.BaseURLEnvVar)
+		}
+
 		if result.FileWritten {
 			ui.Info.Printf("Base URL: %s (saved in %s)\n", cfg.GetBaseURL(), result.ConfigPath)
 		} else {
@@ -73,3 +131,87 @@ var authCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// logout removes the profile's stored API key, e.g., before leaving a shared machine.
+func logout() error {
+	if cfg.APIKey == "" {
+		ui.Info.Println("No API key is stored.")
+	} else {
+		if _, err := cfg.ClearAPIKey(); err != nil {
+			return fmt.Errorf("failed to remove API key: %w", err)
+		}
+		ui.Success.Println("Logged out: the API key was removed from the keyring and config file.")
+	}
+
+	if cfg.Profile != config.DefaultProfile {
+		ui.Info.Printf("Profile: %s\n", cfg.Profile)
+	}
+	if os.Getenv(config.APIKeyEnvVar) != "" {
+		ui.Warning.Printf("%s is set in the environment and will still be used; unset it to log out completely.\n", config.APIKeyEnvVar)
+	}
+	return nil
+}
+
+// saveToken prompts for the token described by name and saves it as *stored, kept in
+// the keyring like the API key. Entering nothing removes the stored token.
+func saveToken(name string, stored *string, envVar string) error {
+	token, err := pterm.DefaultInteractiveTextInput.
+		WithDefaultText("Enter " + name + " (leave empty to remove it)").
+		WithMask("*").
+		Show()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	*stored = strings.TrimSpace(token)
+	if _, err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	if *stored == "" {
+		ui.Success.Printf("The %s was removed.\n", name)
+	} else {
+		ui.Success.Printf("The %s was saved.\n", name)
+	}
+	if cfg.Profile != config.DefaultProfile {
+		ui.Info.Printf("Profile: %s\n", cfg.Profile)
+	}
+	if os.Getenv(envVar) != "" {
+		ui.Warning.Printf("%s is set in the environment and will override the saved %s.\n", envVar, name)
+	}
+	return nil
+}
+
+// readAPIKey prompts for an API key without echoing it.
+func readAPIKey() (string, error) {
+	apiKey, err := pterm.DefaultInteractiveTextInput.
+		WithDefaultText("Enter API Key").
+		WithMask("*").
+		Show()
+	if err != nil {
+		return "", fmt.Errorf("failed to read API key: %w", err)
+	}
+	return strings.TrimSpace(apiKey), nil
+}
+
+// verifyAuth checks that baseURL is a Repoman server that accepts apiKey by fetching
+// the list of courses, bypassing the API cache.
+func verifyAuth(ctx context.Context, baseURL, apiKey string) error {
+	client, err := api.NewClient(baseURL, apiKey)
+	if err != nil {
+		return err
+	}
+	client.SetUserAgent("repoman/" + version)
+	proxyURL, err := cfg.GetProxy()
+	if err != nil {
+		return err
+	}
+	if proxyURL != nil {
+		if err := client.SetProxy(proxyURL); err != nil {
+			return err
+		}
+	}
+
+	ui.Dim.Printf("Checking the settings with %s...\n", baseURL)
+	_, err = client.GetCoursesCtx(ctx)
+	return err
+}
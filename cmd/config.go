@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/liffiton/repoman/internal/config"
+	"github.com/liffiton/repoman/internal/ui"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	configCmd.AddCommand(configProfilesCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect repoman configuration",
+}
+
+var configProfilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "List configuration profiles",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profiles, err := config.ListProfiles()
+		if err != nil {
+			return fmt.Errorf("failed to list profiles: %w", err)
+		}
+
+		ui.PrintHeader("Configuration Profiles")
+		pterm.Println()
+
+		for _, p := range profiles {
+			if p == cfg.Profile {
+				fmt.Println(ui.Success.Sprint("* ") + pterm.Bold.Sprint(p) + ui.Dim.Sprint(" (active)"))
+			} else {
+				fmt.Println("  " + p)
+			}
+		}
+
+		// The active profile may not be saved yet (e.g., selected with --profile before running auth).
+		if !slices.Contains(profiles, cfg.Profile) {
+			fmt.Println(ui.Success.Sprint("* ") + pterm.Bold.Sprint(cfg.Profile) + ui.Dim.Sprint(" (active, not configured)"))
+		}
+
+		return nil
+	},
+}
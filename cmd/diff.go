@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/liffiton/repoman/internal/git"
+	"github.com/liffiton/repoman/internal/ui"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// defaultDiffConcurrency is the number of concurrent diffs when not configured.
+const defaultDiffConcurrency = 20
+
+var diffStat bool
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffStat, "stat", false, "Show a summary of changed files instead of the full diff")
+	diffCmd.Flags().IntVar(&concurrency, "concurrency", defaultDiffConcurrency, "Number of repositories to diff concurrently")
+	addRefreshFlag(diffCmd)
+	rootCmd.AddCommand(diffCmd)
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show uncommitted local changes in each cloned repository",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workers, err := resolveConcurrency(cmd, defaultDiffConcurrency)
+		if err != nil {
+			return err
+		}
+
+		ctx, err := loadWorkspaceContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		ui.PrintHeader(fmt.Sprintf("Local changes for %s", pterm.Bold.Sprintf("%s - %s", ctx.Wcfg.CourseName, ctx.Wcfg.AssignmentName)))
+		if ctx.OrigDir != ctx.Wcfg.Root {
+			ui.Dim.Printf("Workspace: %s\n", ctx.Wcfg.Root)
+		}
+		pterm.Println()
+
+		// Only repositories that have been cloned can have local changes.
+		var gitRepos []git.RepoInfo
+		for _, r := range ctx.Repos {
+			if _, err := os.Stat(r.Name); err == nil {
+				gitRepos = append(gitRepos, git.RepoInfo{
+					Name: r.Name,
+					Path: r.Name,
+				})
+			}
+		}
+
+		if len(gitRepos) == 0 {
+			fmt.Println("No cloned repositories found. Run 'repoman sync' first.")
+			return nil
+		}
+
+		var diffArgs []string
+		if diffStat {
+			diffArgs = append(diffArgs, "--stat")
+		}
+
+		manager := git.NewManager(workers)
+		results := manager.DiffAllCtx(cmd.Context(), gitRepos, diffArgs, nil)
+
+		changed := 0
+		for _, r := range results {
+			if r.Error != nil {
+				ui.Error.Printf("Error getting diff for %s: %v\n", r.Name, r.Error)
+				continue
+			}
+			if r.Diff == "" {
+				continue
+			}
+			changed++
+			fmt.Println(pterm.Bold.Sprint("==> " + r.Name))
+			fmt.Println(strings.TrimRight(r.Diff, "\n"))
+			fmt.Println()
+		}
+
+		if changed == 0 {
+			fmt.Println("No local changes.")
+			return nil
+		}
+		fmt.Printf("%d/%d repositories have local changes.\n", changed, len(gitRepos))
+		return nil
+	},
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/liffiton/repoman/internal/api"
+	"github.com/liffiton/repoman/internal/config"
+	"github.com/liffiton/repoman/internal/ui"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	addRefreshFlag(infoCmd)
+	rootCmd.AddCommand(infoCmd)
+}
+
+var infoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show the course and assignment of the current workspace",
+	Long: `Show the workspace containing the current directory: its root, course, assignment,
+and the base URL in use, along with how many repositories the assignment has and how many
+of them are cloned. Nothing is fetched from or changed in the repositories.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ui.PrintHeader("Workspace Info")
+		pterm.Println()
+
+		origDir, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		if _, err := config.FindWorkspaceRoot(); errors.Is(err, os.ErrNotExist) {
+			ui.Warning.Println("Not in a workspace. Run 'repoman init' to create one here.")
+			ui.Info.Printf("Base URL: %s\n", cfg.GetBaseURL())
+			return nil
+		}
+		wcfg, err := loadWorkspaceRoot(origDir)
+		if err != nil {
+			return err
+		}
+
+		ui.Info.Printf("Workspace: %s\n", wcfg.Root)
+		ui.Info.Printf("Course: %s (%s)\n", wcfg.CourseName, wcfg.CourseID)
+		ui.Info.Printf("Assignment: %s (%s)\n", wcfg.AssignmentName, wcfg.AssignmentID)
+		if wcfg.Layout != "" {
+			ui.Info.Printf("Layout: %s\n", wcfg.Layout)
+		}
+		if cfg.Profile != config.DefaultProfile {
+			ui.Info.Printf("Profile: %s\n", cfg.Profile)
+		}
+		ui.Info.Printf("Base URL: %s\n", cfg.GetBaseURL())
+
+		// The workspace is still worth describing if the server can't be reached.
+		repos, err := fetchWorkspaceRepos(cmd, wcfg)
+		if err != nil {
+			ui.Warning.Printf("Repositories: unknown (%v)\n", err)
+			return nil
+		}
+		ctx, err := newWorkspaceContext(wcfg, repos, origDir, false)
+		if err != nil {
+			ui.Warning.Printf("Repositories: unknown (%v)\n", err)
+			return nil
+		}
+		cloned := 0
+		for _, r := range ctx.Repos {
+			if _, err := os.Stat(filepath.Join(r.Name, ".git")); err == nil {
+				cloned++
+			}
+		}
+		ui.Info.Printf("Repositories: %d (%d cloned)\n", len(ctx.Repos), cloned)
+		return nil
+	},
+}
+
+// fetchWorkspaceRepos returns the repositories of the workspace's assignment, from the
+// API cache if it is fresh.
+func fetchWorkspaceRepos(cmd *cobra.Command, wcfg *config.WorkspaceConfig) ([]api.Repo, error) {
+	if err := requireAuth(); err != nil {
+		return nil, err
+	}
+	client, err := newAPIClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.GetAssignmentReposCtx(cmd.Context(), wcfg.AssignmentID)
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -12,14 +13,37 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	initLayout string
+	initHTTP   bool
+)
+
 func init() {
+	initCmd.Flags().StringVar(&courseFlag, "course", "", "Course ID or name to use without prompting (requires --assignment)")
+	initCmd.Flags().StringVar(&assignmentFlag, "assignment", "", "Assignment ID or name to use without prompting (requires --course)")
+	initCmd.MarkFlagsRequiredTogether("course", "assignment")
+	initCmd.Flags().StringVar(&initLayout, "layout", config.LayoutFlat, "Directory layout for clones: flat (<repo>), owner (<owner>-<repo>), or nested (<owner>/<repo>)")
+	initCmd.Flags().BoolVar(&initHTTP, "http", false, "Make sync use HTTP instead of SSH in this workspace by default")
+	addRefreshFlag(initCmd)
 	rootCmd.AddCommand(initCmd)
 }
 
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize a new Repoman workspace in the current directory",
+	Long: `Initialize a new Repoman workspace in the current directory, choosing the course and
+assignment from interactive lists.
+
+With --course and --assignment (each an ID or a name), no lists are shown, so that workspaces
+can be set up by scripts. A name that matches several courses or assignments is an error; use
+the ID instead. Running the same command again in an existing workspace for that assignment
+changes nothing.`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.ValidateLayout(initLayout); err != nil {
+			return err
+		}
+
 		ui.PrintHeader("Initialize Current Directory")
 		pterm.Println()
 
@@ -27,30 +51,56 @@ var initCmd = &cobra.Command{
 			return err
 		}
 
-		// Check for existing workspace
+		client, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		// Resolve the flags before touching an existing workspace, so that a typo can't
+		// lead to overwriting it.
+		var flagWcfg *config.WorkspaceConfig
+		if assignmentFlag != "" {
+			if flagWcfg, err = resolveAssignmentFlags(cmd.Context(), client); err != nil {
+				return err
+			}
+		}
+
+		// Check for existing workspace, whose course and assignment are preselected below
+		var existing *config.WorkspaceConfig
 		if root, err := config.FindWorkspaceRoot(); err == nil {
+			if err := checkNotInClone(root); err != nil {
+				return err
+			}
+			existing, _ = config.LoadWorkspace()
+
 			curr, _ := os.Getwd()
 			var msg string
 			if root == curr {
+				if existing != nil && flagWcfg != nil &&
+					existing.AssignmentID == flagWcfg.AssignmentID && existing.Layout == layoutSetting() &&
+					existing.UseHTTP == initHTTP {
+					ui.Success.Printf("Current directory is already initialized for %s\n", pterm.Bold.Sprint(assignmentTitle(flagWcfg)))
+					return nil
+				}
 				msg = "Current directory is already a Repoman workspace. Overwrite?"
 			} else {
 				ui.Warning.Printf("Found existing Repoman workspace at %s.\n", pterm.Bold.Sprint(root))
 				msg = "Create a nested workspace here?"
 			}
 
-			result, _ := pterm.DefaultInteractiveConfirm.WithDefaultText(msg).WithDefaultValue(false).Show()
-			if !result {
+			if !confirm(msg) {
 				return nil
 			}
 		}
 
-		client, err := api.NewClient(cfg.GetBaseURL(), cfg.APIKey)
-		if err != nil {
-			return err
+		if flagWcfg != nil {
+			flagWcfg.Layout = layoutSetting()
+			flagWcfg.UseHTTP = initHTTP
+			return saveNewWorkspace(flagWcfg)
 		}
 
 		// 1. Select Course
-		courses, err := client.GetCourses()
+		courses, err := client.GetCoursesCtx(cmd.Context())
 		if err != nil {
 			return fmt.Errorf("failed to fetch courses: %w", err)
 		}
@@ -67,58 +117,97 @@ var initCmd = &cobra.Command{
 			courseMap[option] = c
 		}
 
-		selectedCourseOption, err := pterm.DefaultInteractiveSelect.
+		printer := pterm.DefaultInteractiveSelect.
 			WithDefaultText("Select a course").
 			WithOptions(courseOptions).
-			WithMaxHeight(15).
-			Show()
+			WithMaxHeight(15)
+		if existing != nil {
+			for _, c := range courses {
+				if c.ID == existing.CourseID {
+					printer = printer.WithDefaultOption(c.Name)
+					break
+				}
+			}
+		}
+		selectedCourseOption, err := printer.Show()
 		if err != nil {
 			return err
 		}
 		selectedCourse := courseMap[selectedCourseOption]
 
 		// 2. Select Assignment
-		assignments, err := client.GetAssignments(selectedCourse.ID)
-		if err != nil {
-			return fmt.Errorf("failed to fetch assignments: %w", err)
-		}
-
-		if len(assignments) == 0 {
-			return errors.New("no assignments found for this course")
+		var defaultAssignment string
+		if existing != nil && existing.CourseID == selectedCourse.ID {
+			defaultAssignment = existing.AssignmentName
 		}
-
-		var assignmentOptions []string
-		assignmentMap := make(map[string]api.Assignment)
-		for _, a := range assignments {
-			option := a.Name
-			assignmentOptions = append(assignmentOptions, option)
-			assignmentMap[option] = a
-		}
-
-		selectedAssignmentOption, err := pterm.DefaultInteractiveSelect.
-			WithDefaultText("Select an assignment").
-			WithOptions(assignmentOptions).
-			WithMaxHeight(15).
-			Show()
+		selectedAssignment, err := selectAssignment(cmd.Context(), client, selectedCourse.ID, defaultAssignment)
 		if err != nil {
 			return err
 		}
-		selectedAssignment := assignmentMap[selectedAssignmentOption]
 
 		// 3. Save Workspace Config
-		wcfg := &config.WorkspaceConfig{
+		return saveNewWorkspace(&config.WorkspaceConfig{
 			CourseID:       selectedCourse.ID,
 			CourseName:     selectedCourse.Name,
 			AssignmentID:   selectedAssignment.ID,
 			AssignmentName: selectedAssignment.Name,
-		}
+			Layout:         layoutSetting(),
+			UseHTTP:        initHTTP,
+		})
+	},
+}
 
-		if err := wcfg.SaveWorkspace(); err != nil {
-			return fmt.Errorf("failed to save workspace config: %w", err)
-		}
+// layoutSetting returns the workspace layout to record for --layout, which is empty for
+// the default flat layout.
+func layoutSetting() string {
+	if initLayout == config.LayoutFlat {
+		return ""
+	}
+	return initLayout
+}
 
-		ui.Success.Print("Current directory initialized ")
-		fmt.Println("for " + pterm.Bold.Sprintf("%s - %s", selectedCourse.Name, selectedAssignment.Name))
-		return nil
-	},
+// saveNewWorkspace writes a new workspace config in the current directory.
+func saveNewWorkspace(wcfg *config.WorkspaceConfig) error {
+	if err := wcfg.SaveWorkspace(); err != nil {
+		return fmt.Errorf("failed to save workspace config: %w", err)
+	}
+
+	ui.Success.Print("Current directory initialized ")
+	fmt.Println("for " + pterm.Bold.Sprint(assignmentTitle(wcfg)))
+	return nil
+}
+
+// selectAssignment fetches the assignments for a course and prompts the user to pick one.
+// If defaultName is not empty, that assignment is initially selected.
+func selectAssignment(ctx context.Context, client *api.Client, courseID, defaultName string) (api.Assignment, error) {
+	assignments, err := client.GetAssignmentsCtx(ctx, courseID)
+	if err != nil {
+		return api.Assignment{}, fmt.Errorf("failed to fetch assignments: %w", err)
+	}
+
+	if len(assignments) == 0 {
+		return api.Assignment{}, errors.New("no assignments found for this course")
+	}
+
+	var assignmentOptions []string
+	assignmentMap := make(map[string]api.Assignment)
+	for _, a := range assignments {
+		option := a.Name
+		assignmentOptions = append(assignmentOptions, option)
+		assignmentMap[option] = a
+	}
+
+	printer := pterm.DefaultInteractiveSelect.
+		WithDefaultText("Select an assignment").
+		WithOptions(assignmentOptions).
+		WithMaxHeight(15)
+	if _, ok := assignmentMap[defaultName]; ok {
+		printer = printer.WithDefaultOption(defaultName)
+	}
+
+	selectedAssignmentOption, err := printer.Show()
+	if err != nil {
+		return api.Assignment{}, err
+	}
+	return assignmentMap[selectedAssignmentOption], nil
 }
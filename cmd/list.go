@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/liffiton/repoman/internal/api"
+	"github.com/liffiton/repoman/internal/config"
+	"github.com/liffiton/repoman/internal/ui"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+var listJSON bool
+
+func init() {
+	addAssignmentFlags(listCmd, "list")
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "Print the repositories as JSON")
+	addRefreshFlag(listCmd)
+	rootCmd.AddCommand(listCmd)
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the student repositories for an assignment without cloning them",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		client, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		var wcfg *config.WorkspaceConfig
+		if assignmentFlag != "" {
+			wcfg, err = resolveAssignmentFlags(cmd.Context(), client)
+			if err != nil {
+				return err
+			}
+		} else {
+			if courseFlag != "" {
+				return errors.New("--course requires --assignment")
+			}
+			wcfg, err = config.LoadWorkspace()
+			if err != nil {
+				if os.IsNotExist(err) {
+					return errors.New("no workspace found. Run 'repoman init' first, or use --assignment")
+				}
+				return fmt.Errorf("failed to load workspace: %w", err)
+			}
+		}
+
+		repos, err := client.GetAssignmentReposCtx(cmd.Context(), wcfg.AssignmentID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch repositories: %w", err)
+		}
+
+		if listJSON {
+			if repos == nil {
+				repos = []api.Repo{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(repos)
+		}
+
+		ui.PrintHeader("Repositories for " + pterm.Bold.Sprint(assignmentTitle(wcfg)))
+		pterm.Println()
+
+		if len(repos) == 0 {
+			fmt.Println("No student repositories found for this assignment.")
+			return nil
+		}
+
+		results := make([][]string, len(repos)+1)
+		results[0] = []string{"STUDENT/REPO", "URL"}
+		for i, r := range repos {
+			results[i+1] = []string{r.Name, r.URL}
+		}
+		_ = pterm.DefaultTable.WithHasHeader().WithData(results).Render()
+
+		pterm.Println()
+		fmt.Printf("%d repositories\n", len(repos))
+		return nil
+	},
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/liffiton/repoman/internal/git"
+	"github.com/liffiton/repoman/internal/ui"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// defaultMaintenanceConcurrency is the number of concurrent gc runs when not configured.
+// gc is CPU and disk intensive, so this is lower than for network operations.
+const defaultMaintenanceConcurrency = 4
+
+func init() {
+	maintenanceCmd.Flags().IntVar(&concurrency, "concurrency", defaultMaintenanceConcurrency, "Number of repositories to process concurrently")
+	addRefreshFlag(maintenanceCmd)
+	rootCmd.AddCommand(maintenanceCmd)
+}
+
+var maintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Run git garbage collection on all cloned repositories",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workers, err := resolveConcurrency(cmd, defaultMaintenanceConcurrency)
+		if err != nil {
+			return err
+		}
+
+		ctx, err := loadWorkspaceContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		ui.PrintHeader(fmt.Sprintf("Maintenance for %s", pterm.Bold.Sprintf("%s - %s", ctx.Wcfg.CourseName, ctx.Wcfg.AssignmentName)))
+		if ctx.OrigDir != ctx.Wcfg.Root {
+			ui.Dim.Printf("Workspace: %s\n", ctx.Wcfg.Root)
+		}
+		pterm.Println()
+
+		// Only repositories that have been cloned can be maintained.
+		var gitRepos []git.RepoInfo
+		for _, r := range ctx.Repos {
+			if _, err := os.Stat(r.Name); err == nil {
+				gitRepos = append(gitRepos, git.RepoInfo{
+					Name: r.Name,
+					Path: r.Name,
+				})
+			}
+		}
+
+		if len(gitRepos) == 0 {
+			fmt.Println("No cloned repositories found. Run 'repoman sync' first.")
+			return nil
+		}
+
+		bar := ui.StartProgress(len(gitRepos), "Running gc")
+
+		manager := git.NewManager(workers)
+		results := manager.GCAllCtx(cmd.Context(), gitRepos, func() {
+			bar.Increment()
+		})
+
+		fmt.Println() // New line after progress bar
+
+		successCount := 0
+		var reclaimed int64
+		for _, r := range results {
+			switch {
+			case errors.Is(r.Error, git.ErrOperationInProgress):
+				ui.Warning.Printf("Skipped %s: %v\n", r.Name, r.Error)
+			case r.Error != nil:
+				ui.Error.Printf("Error running gc on %s: %v\n", r.Name, r.Error)
+			default:
+				successCount++
+				reclaimed += r.Reclaimed
+			}
+		}
+
+		fmt.Println(ui.Success.Sprint("Maintenance complete. ") + fmt.Sprintf("%d/%d repositories cleaned up, %s reclaimed.", successCount, len(gitRepos), formatBytes(max(reclaimed, 0))))
+
+		return nil
+	},
+}
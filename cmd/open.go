@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/liffiton/repoman/internal/api"
+	"github.com/liffiton/repoman/internal/git"
+	"github.com/liffiton/repoman/internal/ui"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+var openWeb bool
+
+func init() {
+	openCmd.Flags().BoolVar(&openWeb, "web", false, "Open the repository's page on the Git host in a browser instead")
+	addRefreshFlag(openCmd)
+	rootCmd.AddCommand(openCmd)
+}
+
+var openCmd = &cobra.Command{
+	Use:   "open <name>",
+	Short: "Open a student's repository in your editor, file manager, or browser",
+	Long: `Open a student's cloned repository in $VISUAL or $EDITOR, or in the system file manager
+if neither is set. With --web, open the repository's page on the Git host in a browser.
+
+The name is matched against the assignment's repositories, ignoring case: an exact match wins,
+then names containing it, then names containing its letters in order (e.g., "jsmth" matches
+"jsmith-lab1"). If several repositories match, you are asked to choose one.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, err := loadWorkspaceContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		repo, err := chooseRepo(ctx.Repos, args[0])
+		if err != nil {
+			return err
+		}
+
+		if openWeb {
+			url := strings.TrimSuffix(git.ToHTTP(repo.URL), ".git")
+			ui.Info.Printf("Opening %s\n", url)
+			return openInOS(url)
+		}
+
+		path := filepath.Join(ctx.Wcfg.Root, repo.Name)
+		if _, err := os.Stat(path); err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("%s has not been cloned. Run 'repoman sync' first, or use --web", repo.Name)
+			}
+			return fmt.Errorf("failed to access %s: %w", path, err)
+		}
+
+		editor := os.Getenv("VISUAL")
+		if editor == "" {
+			editor = os.Getenv("EDITOR")
+		}
+		if editor == "" {
+			ui.Info.Printf("Opening %s\n", path)
+			return openInOS(path)
+		}
+
+		// The editor may include arguments (e.g., "code --wait").
+		fields := strings.Fields(editor)
+		editorCmd := exec.Command(fields[0], append(fields[1:], path)...) //#nosec G204
+		editorCmd.Stdin, editorCmd.Stdout, editorCmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := editorCmd.Run(); err != nil {
+			return fmt.Errorf("failed to run editor %q: %w", editor, err)
+		}
+		return nil
+	},
+}
+
+// chooseRepo returns the repository best matching query (see matchRepos), asking the
+// user to pick one if several match equally well.
+func chooseRepo(repos []api.Repo, query string) (api.Repo, error) {
+	matches := matchRepos(repos, query)
+	switch len(matches) {
+	case 0:
+		return api.Repo{}, fmt.Errorf("no repository matches %q", query)
+	case 1:
+		return matches[0], nil
+	}
+
+	names := make([]string, len(matches))
+	for i, r := range matches {
+		names[i] = r.Name
+	}
+	chosen, err := pterm.DefaultInteractiveSelect.
+		WithDefaultText(fmt.Sprintf("%d repositories match %q", len(matches), query)).
+		WithOptions(names).
+		WithMaxHeight(15).
+		Show()
+	if err != nil {
+		return api.Repo{}, err
+	}
+	for _, r := range matches {
+		if r.Name == chosen {
+			return r, nil
+		}
+	}
+	return api.Repo{}, errors.New("no repository selected")
+}
+
+// matchRepos returns the repositories whose names match query, ignoring case. An exact
+// match is returned alone; otherwise the names containing query are returned, or, if
+// there are none, the names containing its characters in order.
+func matchRepos(repos []api.Repo, query string) []api.Repo {
+	query = strings.ToLower(query)
+	var contains, subsequence []api.Repo
+	for _, r := range repos {
+		name := strings.ToLower(r.Name)
+		switch {
+		case name == query:
+			return []api.Repo{r}
+		case strings.Contains(name, query):
+			contains = append(contains, r)
+		case isSubsequence(query, name):
+			subsequence = append(subsequence, r)
+		}
+	}
+	if len(contains) > 0 {
+		return contains
+	}
+	return subsequence
+}
+
+// isSubsequence reports whether the characters of sub appear in s in order.
+func isSubsequence(sub, s string) bool {
+	want := []rune(sub)
+	for _, c := range s {
+		if len(want) > 0 && c == want[0] {
+			want = want[1:]
+		}
+	}
+	return len(want) == 0
+}
+
+// openInOS opens a file, directory, or URL with the operating system's default handler.
+func openInOS(target string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", target)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		cmd = exec.Command("xdg-open", target)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open %s: %w", target, err)
+	}
+	// Don't wait for the handler, which may keep running (e.g., a new browser).
+	return cmd.Process.Release()
+}
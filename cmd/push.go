@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/liffiton/repoman/internal/git"
+	"github.com/liffiton/repoman/internal/ui"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// defaultPushConcurrency is the number of concurrent commits/pushes when not configured.
+const defaultPushConcurrency = 6
+
+var commitMessage string
+
+func init() {
+	pushCmd.Flags().StringVarP(&commitMessage, "message", "m", "", "Commit message (required)")
+	_ = pushCmd.MarkFlagRequired("message")
+	pushCmd.Flags().IntVar(&concurrency, "concurrency", defaultPushConcurrency, "Number of repositories to commit/push concurrently")
+	addRefreshFlag(pushCmd)
+	rootCmd.AddCommand(pushCmd)
+}
+
+var pushCmd = &cobra.Command{
+	Use:   "push -m MESSAGE PATH...",
+	Short: "Commit the given files in each cloned repository and push them",
+	Long: `Commit the given files in each cloned repository and push them.
+
+PATH arguments are relative to each repository (e.g., FEEDBACK.md); use "." to commit
+all changes. Repositories with nothing to commit are still pushed, so commits left over
+from an earlier failed push are sent.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if commitMessage == "" {
+			return errors.New("commit message must not be empty")
+		}
+
+		workers, err := resolveConcurrency(cmd, defaultPushConcurrency)
+		if err != nil {
+			return err
+		}
+
+		ctx, err := loadWorkspaceContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		ui.PrintHeader(fmt.Sprintf("Pushing changes for %s", pterm.Bold.Sprintf("%s - %s", ctx.Wcfg.CourseName, ctx.Wcfg.AssignmentName)))
+		if ctx.OrigDir != ctx.Wcfg.Root {
+			ui.Dim.Printf("Workspace: %s\n", ctx.Wcfg.Root)
+		}
+		pterm.Println()
+
+		// Only repositories that have been cloned can be pushed.
+		var gitRepos []git.RepoInfo
+		for _, r := range ctx.Repos {
+			if _, err := os.Stat(r.Name); err == nil {
+				gitRepos = append(gitRepos, git.RepoInfo{
+					Name: r.Name,
+					Path: r.Name,
+				})
+			}
+		}
+
+		if len(gitRepos) == 0 {
+			fmt.Println("No cloned repositories found. Run 'repoman sync' first.")
+			return nil
+		}
+
+		bar := ui.StartProgress(len(gitRepos), "Pushing")
+
+		manager := git.NewManager(workers)
+		results := manager.CommitAndPushAllCtx(cmd.Context(), gitRepos, commitMessage, args, func() {
+			bar.Increment()
+		})
+
+		fmt.Println() // New line after progress bar
+
+		committed, unchanged := 0, 0
+		for _, r := range results {
+			switch {
+			case r.Error != nil:
+				ui.Error.Printf("Error pushing %s: %v\n", r.Name, r.Error)
+			case r.Committed:
+				committed++
+			default:
+				unchanged++
+				ui.Dim.Printf("Nothing to commit in %s\n", r.Name)
+			}
+		}
+
+		fmt.Println(ui.Success.Sprint("Push complete. ") + fmt.Sprintf("%d/%d repositories committed and pushed, %d with nothing to commit.", committed, len(gitRepos), unchanged))
+
+		return nil
+	},
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/liffiton/repoman/internal/git"
+	"github.com/liffiton/repoman/internal/ui"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// defaultResetConcurrency is the number of concurrent resets when not configured.
+const defaultResetConcurrency = 20
+
+var (
+	resetRef   string
+	resetClean bool
+)
+
+func init() {
+	resetCmd.Flags().StringVar(&resetRef, "ref", "", "Ref to reset to (default: each branch's upstream, or HEAD if it has none)")
+	resetCmd.Flags().BoolVar(&resetClean, "clean", false, "Also remove untracked files and directories (git clean -fd)")
+	resetCmd.Flags().IntVar(&concurrency, "concurrency", defaultResetConcurrency, "Number of repositories to reset concurrently")
+	addRefreshFlag(resetCmd)
+	rootCmd.AddCommand(resetCmd)
+}
+
+var resetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Discard local changes in all cloned repositories",
+	Long: `Discard local changes in all cloned repositories.
+
+Each repository is reset with 'git reset --hard' to its branch's upstream (or HEAD if it
+has none), discarding uncommitted changes to tracked files and any unpushed commits.
+With --clean, untracked files are removed as well. This cannot be undone.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workers, err := resolveConcurrency(cmd, defaultResetConcurrency)
+		if err != nil {
+			return err
+		}
+
+		ctx, err := loadWorkspaceContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		ui.PrintHeader(fmt.Sprintf("Reset repositories for %s", pterm.Bold.Sprintf("%s - %s", ctx.Wcfg.CourseName, ctx.Wcfg.AssignmentName)))
+		if ctx.OrigDir != ctx.Wcfg.Root {
+			ui.Dim.Printf("Workspace: %s\n", ctx.Wcfg.Root)
+		}
+		pterm.Println()
+
+		// Only repositories that have been cloned can be reset.
+		var gitRepos []git.RepoInfo
+		for _, r := range ctx.Repos {
+			if _, err := os.Stat(r.Name); err == nil {
+				gitRepos = append(gitRepos, git.RepoInfo{
+					Name: r.Name,
+					Path: r.Name,
+				})
+			}
+		}
+
+		if len(gitRepos) == 0 {
+			fmt.Println("No cloned repositories found. Run 'repoman sync' first.")
+			return nil
+		}
+
+		msg := fmt.Sprintf("Discard all local changes and unpushed commits in %d repositories?", len(gitRepos))
+		if resetClean {
+			msg = fmt.Sprintf("Discard all local changes, unpushed commits, and untracked files in %d repositories?", len(gitRepos))
+		}
+		if !confirm(msg) {
+			return nil
+		}
+
+		bar := ui.StartProgress(len(gitRepos), "Resetting")
+
+		manager := git.NewManager(workers)
+		results := manager.ResetAllCtx(cmd.Context(), gitRepos, resetRef, resetClean, func() {
+			bar.Increment()
+		})
+
+		fmt.Println() // New line after progress bar
+
+		changed, failed := 0, 0
+		for _, r := range results {
+			switch {
+			case r.Error != nil:
+				failed++
+				ui.Error.Printf("Error resetting %s: %v\n", r.Name, r.Error)
+			case r.Changed:
+				changed++
+				fmt.Printf("Discarded changes in %s\n", r.Name)
+			}
+		}
+
+		fmt.Println(ui.Success.Sprint("Reset complete. ") + fmt.Sprintf("%d/%d repositories had changes discarded, %d failed.", changed, len(gitRepos), failed))
+
+		return nil
+	},
+}
@@ -1,16 +1,33 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/liffiton/repoman/internal/config"
+	"github.com/liffiton/repoman/internal/git"
+	"github.com/liffiton/repoman/internal/ui"
+	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 )
 
+// noColorEnvVar disables colored output when set to any non-empty value (https://no-color.org).
+const noColorEnvVar = "NO_COLOR"
+
 var (
-	cfg     *config.Config
-	version = "dev"
+	cfg       *config.Config
+	profile   string
+	noColor   bool
+	quiet     bool
+	verbose   bool
+	logFile   string
+	assumeYes bool
+	version   = "dev"
 )
 
 var rootCmd = &cobra.Command{
@@ -23,22 +40,82 @@ var rootCmd = &cobra.Command{
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		var err error
 		cmd.SilenceUsage = true // don't print usage for execution errors
-		cfg, err = config.Load()
+		if noColor || os.Getenv(noColorEnvVar) != "" {
+			pterm.DisableColor() // also covers the ui styles, tables, and progress bars
+		}
+		switch {
+		case quiet:
+			ui.SetVerbosity(ui.VerbosityQuiet)
+		case verbose:
+			ui.SetVerbosity(ui.VerbosityVerbose)
+			git.SetLogger(log.New(os.Stderr, "[git] ", log.Ltime))
+		}
+		if logFile != "" {
+			if err := openLogFile(logFile); err != nil {
+				return err
+			}
+			structuredLogger.Info("command", "command", cmd.CommandPath(), "args", args, "version", version)
+		}
+		cfg, err = config.Load(profile)
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
+		proxyURL, err := cfg.GetProxy()
+		if err != nil {
+			return err
+		}
+		if proxyURL != nil {
+			git.SetProxy(proxyURL.String())
+		}
+		startUpdateCheck(cmd)
 		return nil
 	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		printUpdateNotice()
+	},
+}
+
+// structuredLogger writes JSON records to the --log-file, if one was given.
+var structuredLogger *slog.Logger
+
+// openLogFile opens path for appending and directs the git package's structured log to it.
+// The file is left open until the process exits.
+func openLogFile(path string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) //#nosec G304
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	structuredLogger = slog.New(slog.NewJSONHandler(f, nil))
+	git.SetStructuredLogger(structuredLogger)
+	return nil
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// Ctrl-C (or SIGTERM) cancels the command's context, stopping in-flight git operations.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	if err != nil {
 		os.Exit(1)
 	}
 }
 
 func init() {
-	// Root flags if any
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Configuration profile to use (default from $"+config.ProfileEnvVar+" or \""+config.DefaultProfile+"\")")
+	_ = rootCmd.RegisterFlagCompletionFunc("profile", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		profiles, err := config.ListProfiles()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return profiles, cobra.ShellCompDirectiveNoFileComp
+	})
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output (also set by $"+noColorEnvVar+")")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Only print results, warnings, and errors")
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Log each git command and API request")
+	rootCmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "Append a JSON log of each git command, sync, and API request to a file")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "Answer yes to confirmation prompts, e.g., for scripts")
+	rootCmd.PersistentFlags().BoolVar(&noUpdateCheck, "no-update-check", false, "Skip the automatic check for a newer version")
 }
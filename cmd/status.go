@@ -2,111 +2,730 @@
 package cmd
 
 import (
+	"context"
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"math"
+	"os"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/liffiton/repoman/internal/git"
 	"github.com/liffiton/repoman/internal/ui"
+	"github.com/liffiton/repoman/internal/webhook"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 )
 
-var noFetch bool
+// defaultStatusConcurrency is the number of concurrent status checks when not configured.
+const defaultStatusConcurrency = 20
+
+// defaultFetchConcurrency is the number of status checks that fetch at once when not
+// configured: enough to keep the network busy, but below the connection limits of the
+// common Git hosts.
+const defaultFetchConcurrency = 8
+
+var (
+	noFetch        bool
+	showSize       bool
+	showCommits    bool
+	firstParent    bool
+	noMerges       bool
+	staleSince     string
+	csvPath        string
+	statusFormat   string
+	watchInterval  time.Duration
+	staleDays      int
+	starterCommits int
+	fetchWorkers   int
+	cachedOnly     bool
+	groupBy        string
+)
+
+// minWatchInterval keeps status --watch from fetching from the Git host too often.
+const minWatchInterval = 2 * time.Second
 
 func init() {
 	statusCmd.Flags().BoolVarP(&noFetch, "no-fetch", "n", false, "Do not fetch from remote")
+	statusCmd.Flags().BoolVar(&showSize, "show-size", false, "Show each repository's size on disk")
+	statusCmd.Flags().BoolVar(&showCommits, "show-commits", false, "Show the number of commits on each repository's default branch, with a class total")
+	statusCmd.Flags().BoolVar(&firstParent, "first-parent", false, "With --show-commits, leave out commits brought in by merges (e.g., starter code updates)")
+	statusCmd.Flags().BoolVar(&noMerges, "no-merges", false, "With --show-commits, leave out merge commits")
+	statusCmd.Flags().IntVar(&starterCommits, "starter-commits", 0, "With --show-commits, the number of commits in the starter code, subtracted from each count (default from the workspace's starter_commits)")
+	statusCmd.Flags().IntVar(&staleDays, "stale-days", 0, "Highlight last commits older than this many days in red (0 to disable)")
+	statusCmd.Flags().StringVar(&staleSince, "stale-since", "", "Only show repositories with no commits since a duration ago (e.g., 168h) or a date (YYYY-MM-DD)")
+	statusCmd.Flags().StringVar(&csvPath, "csv", "", "Write the status as CSV to a file (--csv=FILE), or to stdout instead of the table (--csv)")
+	statusCmd.Flags().Lookup("csv").NoOptDefVal = "-"
+	statusCmd.Flags().DurationVar(&watchInterval, "watch", 0, "Refresh the status every interval until interrupted (--watch=30s; default 10s)")
+	statusCmd.Flags().Lookup("watch").NoOptDefVal = "10s"
+	statusCmd.Flags().StringVar(&groupBy, "group-by", "", "Show a table per group of repositories instead of one table (\"state\": Clean, Behind, Dirty, Missing, Error)")
+	statusCmd.Flags().BoolVar(&cachedOnly, "cached", false, "Show the status saved by the last run without checking the repositories")
+	statusCmd.Flags().StringVar(&statusFormat, "format", "", "Print one line per repository from a Go template (e.g., '{{.Name}} {{.SyncState}}') instead of the table")
+	statusCmd.MarkFlagsMutuallyExclusive("watch", "csv")
+	statusCmd.MarkFlagsMutuallyExclusive("watch", "format")
+	statusCmd.MarkFlagsMutuallyExclusive("csv", "format")
+	statusCmd.MarkFlagsMutuallyExclusive("watch", "cached")
+	addTimingsFlag(statusCmd)
+	addTimeoutFlag(statusCmd)
+	statusCmd.MarkFlagsMutuallyExclusive("watch", "timings")
+	statusCmd.MarkFlagsMutuallyExclusive("cached", "timings")
+	statusCmd.Flags().IntVar(&concurrency, "concurrency", defaultStatusConcurrency, "Number of repositories to check concurrently")
+	statusCmd.Flags().IntVar(&fetchWorkers, "fetch-concurrency", defaultFetchConcurrency, "Number of those repositories to fetch concurrently")
+	addAssignmentFlags(statusCmd, "check")
+	addReposFileFlag(statusCmd)
+	addRefreshFlag(statusCmd)
 	rootCmd.AddCommand(statusCmd)
 }
 
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show status of all student repositories in the workspace",
+	Args:  cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx, err := loadWorkspaceContext()
+		workers, err := resolveConcurrency(cmd, defaultStatusConcurrency)
 		if err != nil {
 			return err
 		}
+		if err := checkTimeoutFlag(cmd); err != nil {
+			return err
+		}
+		if !cmd.Flags().Changed("fetch-concurrency") && cfg.FetchConcurrency != 0 {
+			fetchWorkers = cfg.FetchConcurrency
+		}
+		if fetchWorkers <= 0 {
+			return fmt.Errorf("fetch concurrency must be a positive integer, got %d", fetchWorkers)
+		}
 
-		ui.PrintHeader("Status for " + pterm.Bold.Sprintf("%s - %s", ctx.Wcfg.CourseName, ctx.Wcfg.AssignmentName))
-		if ctx.OrigDir != ctx.Wcfg.Root {
-			ui.Dim.Printf("Workspace: %s\n", ctx.Wcfg.Root)
+		var cutoff time.Time
+		if staleSince != "" {
+			cutoff, err = parseStaleSince(staleSince, time.Now())
+			if err != nil {
+				return err
+			}
+		}
+		if !cmd.Flags().Changed("stale-days") {
+			staleDays = cfg.StaleDays
+		}
+		if staleDays < 0 {
+			return fmt.Errorf("stale days must not be negative, got %d", staleDays)
+		}
+		if groupBy != "" && groupBy != groupByState {
+			return fmt.Errorf("invalid --group-by %q: must be %q", groupBy, groupByState)
+		}
+		if cmd.Flags().Changed("watch") && watchInterval < minWatchInterval {
+			return fmt.Errorf("--watch interval must be at least %s", minWatchInterval)
+		}
+		var tmpl *template.Template
+		if statusFormat != "" {
+			if tmpl, err = parseStatusFormat(statusFormat); err != nil {
+				return err
+			}
+		}
+
+		ctx, err := loadWorkspaceContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+		if !cmd.Flags().Changed("starter-commits") {
+			starterCommits = ctx.Wcfg.StarterCommits
+		}
+		if starterCommits < 0 {
+			return fmt.Errorf("starter commits must not be negative, got %d", starterCommits)
 		}
-		pterm.Println()
 
-		bar, _ := ui.Progressbar.WithTotal(len(ctx.Repos)).WithTitle("Checking status").Start()
+		// The last run's statuses are shown while checking again, or instead with --cached.
+		var cached []git.RepoStatus
+		var cachedAt time.Time
+		if !ctx.FromFlags {
+			cached, cachedAt = loadStatusCache(ctx.Wcfg.Root, ctx.Repos, showSize, commitCountMode())
+		}
+		if cachedOnly && cached == nil {
+			return errors.New("no saved status for this workspace's repositories; run 'repoman status' without --cached")
+		}
 
-		manager := git.NewManager(20)
+		// CSV on stdout or --format replaces the normal output; the progress bar goes to stderr.
+		csvToStdout := csvPath == "-"
+		plainOutput := csvToStdout || tmpl != nil
+
+		if !plainOutput {
+			ui.PrintHeader("Status for " + pterm.Bold.Sprint(assignmentTitle(ctx.Wcfg)))
+			if ctx.OrigDir != ctx.Wcfg.Root {
+				ui.Dim.Printf("Workspace: %s\n", ctx.Wcfg.Root)
+			}
+			pterm.Println()
+		}
+
+		manager := git.NewManagerWithOptions(git.WithConcurrency(workers), git.WithNetworkConcurrency(fetchWorkers), git.WithOpTimeout(opTimeout))
 		var gitRepos []git.RepoInfo
 		for _, r := range ctx.Repos {
 			gitRepos = append(gitRepos, git.RepoInfo{
-				Name: r.Name,
-				Path: r.Name,
+				Name:               r.Name,
+				URL:                r.URL,
+				Path:               r.Name,
+				MeasureSize:        showSize,
+				CountDefaultBranch: showCommits,
+				CountOptions:       git.CountOptions{FirstParent: firstParent, NoMerges: noMerges},
+				BaselineCommits:    starterCommits,
 			})
 		}
 
-		repoStatuses := manager.StatusAllCtx(cmd.Context(), gitRepos, !noFetch, func() {
-			bar.Increment()
-		})
+		if cmd.Flags().Changed("watch") {
+			return watchStatus(cmd.Context(), manager, gitRepos)
+		}
+		if cachedOnly {
+			return printCachedStatus(cached, cachedAt, cutoff, csvToStdout, tmpl)
+		}
 
-		sort.Slice(repoStatuses, func(i, j int) bool {
-			iBad := repoStatuses[i].Status == git.StatusMissing || repoStatuses[i].Status == git.StatusError || repoStatuses[i].Error != nil
-			jBad := repoStatuses[j].Status == git.StatusMissing || repoStatuses[j].Status == git.StatusError || repoStatuses[j].Error != nil
-			if iBad != jBad {
-				return !iBad
+		// With a saved status, its table is shown and updated in place instead of a progress bar.
+		var repoStatuses []git.RepoStatus
+		shownLive := cached != nil && !plainOutput
+		if shownLive {
+			repoStatuses, err = refreshStatus(cmd.Context(), manager, gitRepos, cached, cachedAt, cutoff)
+			if err != nil {
+				return err
 			}
-			return repoStatuses[i].Name < repoStatuses[j].Name
-		})
-
-		fmt.Println() // New line after progress bar
+		} else {
+			bar := ui.StartProgress(len(ctx.Repos), "Checking status")
+			repoStatuses = manager.StatusAllReportCtx(cmd.Context(), gitRepos, !noFetch, func(done git.RepoInfo, err error) {
+				bar.IncrementItem(done.Name, err != nil)
+			})
+		}
+		if cmd.Context().Err() != nil {
+			fmt.Println() // New line after progress bar
+			completed := 0
+			for _, s := range repoStatuses {
+				// Statuses of repositories that were never checked are left empty.
+				if s.Name != "" && !errors.Is(s.Error, context.Canceled) {
+					completed++
+				}
+			}
+			ui.Warning.Printf("Status cancelled. %d of %d repositories checked.\n", completed, len(repoStatuses))
+			return errors.New("interrupted")
+		}
+		sortStatuses(repoStatuses)
+		if !ctx.FromFlags {
+			saveStatusCache(ctx.Wcfg.Root, ctx.Repos, repoStatuses, showSize, commitCountMode())
+		}
 
-		maxCommits := 0
+		event := newWebhookEvent("status", ctx.Wcfg)
 		for _, s := range repoStatuses {
-			maxCommits = max(maxCommits, s.CommitCount)
+			result := webhook.RepoResult{
+				Name:        s.Name,
+				OK:          s.Error == nil && s.Status != git.StatusMissing && s.Status != git.StatusError,
+				Branch:      s.Branch,
+				Status:      s.Status,
+				SyncState:   s.SyncState,
+				CommitCount: s.CommitCount,
+			}
+			if s.Error != nil {
+				result.Error = s.Error.Error()
+			}
+			event.Repos = append(event.Repos, result)
 		}
+		waitWebhook := postWebhook(ctx.Wcfg, event)
+		defer waitWebhook()
 
-		results := make([][]string, len(repoStatuses)+1)
-		results[0] = []string{"STUDENT/REPO", "BRANCH", "COMMITS", "LAST COMMIT", "LOCAL STATUS", "SYNC STATE"}
+		// The webhook reports every repository; the filter only affects what is shown.
+		shown := repoStatuses
+		if !cutoff.IsZero() {
+			shown, _ = filterStale(repoStatuses, cutoff)
+		}
 
-		for i, s := range repoStatuses {
-			if s.Error != nil {
-				results[i+1] = []string{
-					s.Name,
-					"ERROR",
-					dimPlaceholder(7),
-					dimPlaceholder(),
-					pterm.Red(s.Error.Error()),
-					dimPlaceholder(),
-				}
-				continue
+		if csvToStdout {
+			return writeStatusCSV(os.Stdout, shown, showSize, showCommits)
+		}
+		if tmpl != nil {
+			// Like CSV, the lines are for scripts, so nothing else goes to stdout.
+			return writeStatusFormat(os.Stdout, shown, tmpl)
+		}
+
+		if !shownLive {
+			fmt.Println() // New line after progress bar
+			fmt.Print(renderStatus(repoStatuses, cutoff))
+		}
+
+		if timings > 0 {
+			names := make([]string, len(repoStatuses))
+			durations := make([]time.Duration, len(repoStatuses))
+			for i, s := range repoStatuses {
+				names[i], durations[i] = s.Name, s.Duration
 			}
+			printTimings(names, durations, timings)
+		}
 
-			commits := formatCommitCount(s.CommitCount, maxCommits)
-			if s.Status == git.StatusMissing {
-				commits = dimPlaceholder(7)
+		if csvPath != "" {
+			if err := saveStatusCSV(csvPath, shown, showSize, showCommits); err != nil {
+				return err
 			}
+			pterm.Println()
+			ui.Success.Print("Status written ")
+			fmt.Printf("to %s\n", csvPath)
+		}
+		return nil
+	},
+}
+
+// printCachedStatus prints statuses saved at cachedAt, as CSV on stdout if csvToStdout,
+// with tmpl if it is not nil, and otherwise as the status table, also writing them to
+// --csv's file if set.
+func printCachedStatus(statuses []git.RepoStatus, cachedAt, cutoff time.Time, csvToStdout bool, tmpl *template.Template) error {
+	sortStatuses(statuses)
+	shown := statuses
+	if !cutoff.IsZero() {
+		shown, _ = filterStale(statuses, cutoff)
+	}
+	if csvToStdout {
+		return writeStatusCSV(os.Stdout, shown, showSize, showCommits)
+	}
+	if tmpl != nil {
+		return writeStatusFormat(os.Stdout, shown, tmpl)
+	}
 
-			branch := s.Branch
-			if branch == "" {
-				branch = dimPlaceholder()
+	fmt.Print(renderStatus(statuses, cutoff))
+	ui.Dim.Printf("Saved status from %s; run without --cached to check again.\n", cachedAt.Local().Format("2006-01-02 15:04"))
+
+	if csvPath != "" {
+		if err := saveStatusCSV(csvPath, shown, showSize, showCommits); err != nil {
+			return err
+		}
+		pterm.Println()
+		ui.Success.Print("Status written ")
+		fmt.Printf("to %s\n", csvPath)
+	}
+	return nil
+}
+
+// refreshStatus checks the status of repos while showing the cached statuses from
+// cachedAt, redrawing the table in place as each repository's new status arrives. The
+// final table is left on screen.
+func refreshStatus(ctx context.Context, manager *git.Manager, repos []git.RepoInfo, cached []git.RepoStatus, cachedAt, cutoff time.Time) ([]git.RepoStatus, error) {
+	area, err := pterm.DefaultArea.Start()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = area.Stop() }()
+
+	shown := slices.Clone(cached)
+	sortStatuses(shown)
+	checked := 0
+	redraw := func() {
+		area.Update(renderStatus(shown, cutoff) + "\n" +
+			ui.Dim.Sprintf("Showing status from %s; checking again (%d/%d)...", cachedAt.Local().Format("2006-01-02 15:04"), checked, len(repos)))
+	}
+	redraw()
+
+	statuses := manager.StatusAllStreamCtx(ctx, repos, !noFetch, func(s git.RepoStatus) {
+		checked++
+		if i := slices.IndexFunc(shown, func(c git.RepoStatus) bool { return c.Name == s.Name }); i >= 0 {
+			shown[i] = s
+		} else {
+			// Left out of the cache, e.g., after an error.
+			shown = append(shown, s)
+		}
+		sortStatuses(shown)
+		redraw()
+	})
+	if ctx.Err() != nil {
+		// Interrupted mid-check: keep the partly refreshed table.
+		area.Update(renderStatus(shown, cutoff))
+		return statuses, nil
+	}
+
+	final := slices.Clone(statuses)
+	sortStatuses(final)
+	area.Update(renderStatus(final, cutoff))
+	return statuses, nil
+}
+
+// watchStatus checks the status of repos repeatedly, every watchInterval, redrawing the
+// table in place after each check until ctx is canceled (e.g., by Ctrl-C).
+func watchStatus(ctx context.Context, manager *git.Manager, repos []git.RepoInfo) error {
+	area, err := pterm.DefaultArea.Start()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = area.Stop() }()
+
+	area.Update(ui.Dim.Sprint("Checking status..."))
+	for {
+		statuses := manager.StatusAllCtx(ctx, repos, !noFetch, nil)
+		if ctx.Err() != nil {
+			// Interrupted mid-check: keep the last complete table.
+			return nil
+		}
+		sortStatuses(statuses)
+
+		var cutoff time.Time
+		if staleSince != "" {
+			// A duration is relative to now, so the cutoff moves with each refresh.
+			cutoff, _ = parseStaleSince(staleSince, time.Now())
+		}
+
+		area.Update(renderStatus(statuses, cutoff) + "\n" +
+			ui.Dim.Sprintf("Updated %s; refreshing every %s. Press Ctrl-C to stop.", time.Now().Format("15:04:05"), watchInterval))
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(watchInterval):
+		}
+	}
+}
+
+// sortStatuses sorts statuses by name, with missing and failed repositories last.
+func sortStatuses(statuses []git.RepoStatus) {
+	sort.Slice(statuses, func(i, j int) bool {
+		iBad := statuses[i].Status == git.StatusMissing || statuses[i].Status == git.StatusError || statuses[i].Error != nil
+		jBad := statuses[j].Status == git.StatusMissing || statuses[j].Status == git.StatusError || statuses[j].Error != nil
+		if iBad != jBad {
+			return !iBad
+		}
+		return statuses[i].Name < statuses[j].Name
+	})
+}
+
+// renderStatus renders the status table for statuses, or a table per group with
+// --group-by. If cutoff is set, only repositories with no commits since then are shown,
+// followed by a summary.
+func renderStatus(statuses []git.RepoStatus, cutoff time.Time) string {
+	total := len(statuses)
+	unchecked := 0
+	if !cutoff.IsZero() {
+		statuses, unchecked = filterStale(statuses, cutoff)
+	}
+
+	// Commit counts are colored relative to the most in any group.
+	maxCommits, maxDefault := 0, 0
+	for _, s := range statuses {
+		maxCommits = max(maxCommits, s.CommitCount)
+		maxDefault = max(maxDefault, s.DefaultBranchCommits)
+	}
+
+	var b strings.Builder
+	mismatched := 0
+	if groupBy == groupByState {
+		groups := groupByStatusState(statuses)
+		for _, name := range statusGroups {
+			if len(groups[name]) == 0 {
+				continue
 			}
+			b.WriteString(pterm.Bold.Sprintf("%s (%d)", name, len(groups[name])) + "\n")
+			table, m := renderStatusTable(groups[name], cutoff, maxCommits, maxDefault)
+			b.WriteString(table)
+			mismatched += m
+		}
+	} else {
+		table, m := renderStatusTable(statuses, cutoff, maxCommits, maxDefault)
+		b.WriteString(table)
+		mismatched = m
+	}
+
+	if showCommits {
+		b.WriteString(defaultBranchSummary(statuses))
+	}
+
+	if mismatched > 0 {
+		b.WriteString("\n" + ui.Warning.Sprintfln("%d repositories pull from a different URL than the assignment's. Run 'repoman sync --fix-remotes' to update them.", mismatched))
+	}
+
+	if !cutoff.IsZero() {
+		fmt.Fprintf(&b, "\n%d/%d repositories have no commits since %s.\n", len(statuses), total-unchecked, cutoff.Format("2006-01-02 15:04"))
+		if unchecked > 0 && !ui.IsQuiet() {
+			b.WriteString(ui.Dim.Sprintf("%d missing or failed repositories were not checked.\n", unchecked))
+		}
+	}
+	return b.String()
+}
+
+// renderStatusTable renders a table of statuses, coloring commit counts relative to
+// maxCommits (maxDefault for default branches), and returns it with the number of
+// repositories with a mismatched remote.
+func renderStatusTable(statuses []git.RepoStatus, cutoff time.Time, maxCommits, maxDefault int) (string, int) {
+	mismatched := 0
+	results := make([][]string, len(statuses)+1)
+	results[0] = []string{"STUDENT/REPO", "BRANCH", "COMMITS", "LAST COMMIT", "LOCAL STATUS", "SYNC STATE"}
+	if showSize {
+		results[0] = append(results[0], "SIZE")
+	}
+	if showCommits {
+		results[0] = append(results[0], "DEFAULT BRANCH")
+	}
 
+	for i, s := range statuses {
+		if s.Error != nil {
 			results[i+1] = []string{
 				s.Name,
-				branch,
-				commits,
-				formatCommitTime(s.LastCommit),
-				colorStatus(s.Status),
-				colorSyncState(s.SyncState),
+				"ERROR",
+				dimPlaceholder(7),
+				dimPlaceholder(),
+				pterm.Red(s.Error.Error()),
+				dimPlaceholder(),
+			}
+			if showSize {
+				results[i+1] = append(results[i+1], dimPlaceholder(sizeWidth))
 			}
+			if showCommits {
+				results[i+1] = append(results[i+1], dimPlaceholder(7))
+			}
+			continue
 		}
 
-		_ = pterm.DefaultTable.WithHasHeader().WithData(results).Render()
+		commits := formatCommitCount(s.CommitCount, maxCommits)
+		if s.Status == git.StatusMissing {
+			commits = dimPlaceholder(7)
+		}
 
-		return nil
-	},
+		branch := s.Branch
+		if branch == "" {
+			branch = dimPlaceholder()
+		} else if git.IsDetached(branch) {
+			// Usually a botched checkout; pulls won't update the repository.
+			branch = pterm.Yellow(branch)
+		}
+
+		lastCommit := formatCommitTime(s.LastCommit)
+		if isStale(s.LastCommit, staleDays, time.Now()) {
+			lastCommit = pterm.Red(lastCommit)
+		}
+		if !cutoff.IsZero() && s.LastCommit.IsZero() {
+			lastCommit = pterm.Yellow("no commits")
+		}
+
+		syncState := colorSyncState(s.SyncState)
+		if s.MismatchedRemote != "" {
+			syncState += pterm.Yellow(" (remote differs)")
+			mismatched++
+		}
+
+		results[i+1] = []string{
+			s.Name,
+			branch,
+			commits,
+			lastCommit,
+			colorStatus(s.Status),
+			syncState,
+		}
+		if showSize {
+			size := fmt.Sprintf("%*s", sizeWidth, formatBytes(s.Size))
+			if s.Status == git.StatusMissing {
+				size = dimPlaceholder(sizeWidth)
+			}
+			results[i+1] = append(results[i+1], size)
+		}
+		if showCommits {
+			defaultCommits := formatCommitCount(s.DefaultBranchCommits, maxDefault)
+			if s.Status == git.StatusMissing {
+				defaultCommits = dimPlaceholder(7)
+			}
+			results[i+1] = append(results[i+1], defaultCommits)
+		}
+	}
+
+	table, _ := pterm.DefaultTable.WithHasHeader().WithData(results).Srender()
+	return table + "\n", mismatched
+}
+
+// defaultBranchSummary returns a line giving the total and median number of commits on
+// the default branches of the repositories in statuses that were checked.
+func defaultBranchSummary(statuses []git.RepoStatus) string {
+	var counts []int
+	total := 0
+	for _, s := range statuses {
+		if s.Error != nil || s.Status == git.StatusMissing || s.Status == git.StatusError {
+			continue
+		}
+		counts = append(counts, s.DefaultBranchCommits)
+		total += s.DefaultBranchCommits
+	}
+	if len(counts) == 0 {
+		return ""
+	}
+	slices.Sort(counts)
+	return fmt.Sprintf("\n%d commits on default branches across %d repositories (median %d, fewest %d).\n",
+		total, len(counts), counts[len(counts)/2], counts[0])
+}
+
+// commitCountMode describes how --show-commits counts commits, so that saved counts are
+// only reused when counted the same way. It is empty without --show-commits.
+func commitCountMode() string {
+	if !showCommits {
+		return ""
+	}
+	return fmt.Sprintf("first-parent=%t,no-merges=%t,starter=%d", firstParent, noMerges, starterCommits)
+}
+
+// Values of --group-by.
+const groupByState = "state"
+
+// Groups of status --group-by state, in the order they are shown.
+const (
+	groupClean   = "Clean"
+	groupBehind  = "Behind"
+	groupDirty   = "Dirty"
+	groupMissing = "Missing"
+	groupError   = "Error"
+)
+
+var statusGroups = []string{groupClean, groupBehind, groupDirty, groupMissing, groupError}
+
+// statusGroup returns the --group-by state group of s: Error and Missing repositories
+// first, then those with local changes or conflicts (Dirty), those needing a pull (Behind,
+// including diverged ones), and all others (Clean), including empty repositories.
+func statusGroup(s git.RepoStatus) string {
+	switch {
+	case s.Error != nil || s.Status == git.StatusError:
+		return groupError
+	case s.Status == git.StatusMissing:
+		return groupMissing
+	case s.Status == git.StatusConflicted || strings.Contains(s.Status, "modified"):
+		return groupDirty
+	case strings.HasPrefix(s.SyncState, "Behind") || strings.HasPrefix(s.SyncState, "Diverged"):
+		return groupBehind
+	}
+	return groupClean
+}
+
+// groupByStatusState partitions statuses by statusGroup, keeping their order.
+func groupByStatusState(statuses []git.RepoStatus) map[string][]git.RepoStatus {
+	groups := make(map[string][]git.RepoStatus)
+	for _, s := range statuses {
+		g := statusGroup(s)
+		groups[g] = append(groups[g], s)
+	}
+	return groups
+}
+
+// parseStaleSince parses a --stale-since value, either a duration before now (e.g.,
+// "168h") or a local date (YYYY-MM-DD), into the cutoff time.
+func parseStaleSince(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("invalid --stale-since %q: duration must not be negative", value)
+		}
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --stale-since %q: must be a duration such as \"168h\" or a date such as \"2024-03-01\"", value)
+}
+
+// filterStale returns the statuses of repositories with no commit after cutoff, including
+// empty repositories. Missing repositories and those with errors are left out, as their
+// last commit is unknown; their number is also returned.
+func filterStale(statuses []git.RepoStatus, cutoff time.Time) (stale []git.RepoStatus, unchecked int) {
+	for _, s := range statuses {
+		if s.Error != nil || s.Status == git.StatusMissing || s.Status == git.StatusError {
+			unchecked++
+			continue
+		}
+		if !s.LastCommit.After(cutoff) {
+			stale = append(stale, s)
+		}
+	}
+	return stale, unchecked
+}
+
+// sizeWidth is the width of the right-aligned SIZE column (e.g., "1023.9 MiB").
+const sizeWidth = 10
+
+// parseStatusFormat parses a --format template for one repository's status line, ending
+// it with a newline if it doesn't have one. The template is tried on an example status,
+// so that a misspelled field is reported before any repository is checked.
+func parseStatusFormat(format string) (*template.Template, error) {
+	if !strings.HasSuffix(format, "\n") {
+		format += "\n"
+	}
+	tmpl, err := template.New("format").Option("missingkey=error").Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --format template: %w", err)
+	}
+	example := git.RepoStatus{Name: "example", Status: "Clean", LastCommit: time.Now()}
+	if err := tmpl.Execute(io.Discard, example); err != nil {
+		return nil, fmt.Errorf("invalid --format template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// writeStatusFormat writes one line per status to w using tmpl (see parseStatusFormat).
+func writeStatusFormat(w io.Writer, statuses []git.RepoStatus, tmpl *template.Template) error {
+	for _, s := range statuses {
+		if err := tmpl.Execute(w, s); err != nil {
+			return fmt.Errorf("failed to format the status of %s: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// saveStatusCSV writes the repository statuses as CSV to the file at path.
+func saveStatusCSV(path string, statuses []git.RepoStatus, withSize, withCommits bool) (err error) {
+	f, err := os.Create(path) //#nosec G304
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("failed to write CSV file: %w", cerr)
+		}
+	}()
+	return writeStatusCSV(f, statuses, withSize, withCommits)
+}
+
+// writeStatusCSV writes the repository statuses as RFC 4180 CSV with the same columns as
+// the status table, plus any error. Commit times are in RFC 3339 format. If withSize is
+// true, a column gives each repository's size in bytes, and if withCommits is true, a
+// final column gives the number of commits on its default branch.
+func writeStatusCSV(w io.Writer, statuses []git.RepoStatus, withSize, withCommits bool) error {
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true // as specified by RFC 4180
+	header := []string{"Student/Repo", "Branch", "Commits", "Last Commit", "Local Status", "Sync State", "Error"}
+	if withSize {
+		header = append(header, "Size (bytes)")
+	}
+	if withCommits {
+		header = append(header, "Default Branch Commits")
+	}
+	_ = cw.Write(header)
+
+	for _, s := range statuses {
+		var commits, lastCommit, errMsg string
+		if s.Error != nil {
+			errMsg = s.Error.Error()
+		} else if s.Status != git.StatusMissing {
+			commits = strconv.Itoa(s.CommitCount)
+		}
+		if !s.LastCommit.IsZero() {
+			lastCommit = s.LastCommit.Format(time.RFC3339)
+		}
+		record := []string{s.Name, s.Branch, commits, lastCommit, s.Status, s.SyncState, errMsg}
+		if withSize {
+			size := ""
+			if s.Error == nil && s.Status != git.StatusMissing {
+				size = strconv.FormatInt(s.Size, 10)
+			}
+			record = append(record, size)
+		}
+		if withCommits {
+			defaultCommits := ""
+			if s.Error == nil && s.Status != git.StatusMissing {
+				defaultCommits = strconv.Itoa(s.DefaultBranchCommits)
+			}
+			record = append(record, defaultCommits)
+		}
+		_ = cw.Write(record)
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
 }
 
 func dimPlaceholder(width ...int) string {
@@ -124,7 +743,7 @@ func colorStatus(status string) string {
 	if strings.HasPrefix(status, "Error: ") {
 		return pterm.Red(status)
 	}
-	if status == "Missing" {
+	if status == git.StatusMissing || status == git.StatusConflicted {
 		return pterm.Red(status)
 	}
 	if strings.Contains(status, "modified") {
@@ -172,6 +791,12 @@ func formatCommitCount(count, maxCommits int) string {
 	return pterm.NewRGB(r, g, b).Sprintf("%s", formatted)
 }
 
+// isStale reports whether a last commit at t is more than days days before now. Empty
+// repositories (a zero t) and a threshold of 0 days are never stale.
+func isStale(t time.Time, days int, now time.Time) bool {
+	return days > 0 && !t.IsZero() && t.Before(now.AddDate(0, 0, -days))
+}
+
 func formatCommitTime(t time.Time) string {
 	if t.IsZero() {
 		return dimPlaceholder()
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/liffiton/repoman/internal/api"
+	"github.com/liffiton/repoman/internal/git"
+)
+
+// statusCacheFileName is the file in the workspace root holding the last status results.
+const statusCacheFileName = ".repoman-status.json"
+
+// statusCache is the last status of a workspace's repositories, shown by the next run of
+// status while it checks them again.
+type statusCache struct {
+	Checked time.Time `json:"checked"`
+	// Repos lists each repository's name and URL when the status was checked; the cache
+	// is only used for the same list of repositories.
+	Repos []string `json:"repos"`
+	Sized bool     `json:"sized,omitempty"`
+	// Counted is how default branch commits were counted; see commitCountMode.
+	Counted  string         `json:"counted,omitempty"`
+	Statuses []cachedStatus `json:"statuses"`
+}
+
+// cachedStatus is a git.RepoStatus without an error, as stored in a statusCache.
+type cachedStatus struct {
+	LastCommit       time.Time `json:"last_commit,omitzero"`
+	Name             string    `json:"name"`
+	Branch           string    `json:"branch,omitempty"`
+	Status           string    `json:"status"`
+	SyncState        string    `json:"sync_state,omitempty"`
+	MismatchedRemote string    `json:"mismatched_remote,omitempty"`
+	Size             int64     `json:"size,omitempty"`
+	CommitCount      int       `json:"commit_count"`
+	DefaultCommits   int       `json:"default_branch_commits,omitempty"`
+}
+
+// statusCacheKey identifies a list of repositories, in any order.
+func statusCacheKey(repos []api.Repo) []string {
+	key := make([]string, len(repos))
+	for i, r := range repos {
+		key[i] = r.Name + " " + r.URL
+	}
+	slices.Sort(key)
+	return key
+}
+
+// loadStatusCache returns the cached statuses of repos in the workspace at root and when
+// they were checked. It returns no statuses if there is no cache, it is unreadable, it was
+// saved for a different list of repositories, sized is set and it lacks sizes, or counted
+// is set and its default branch commits were counted differently.
+func loadStatusCache(root string, repos []api.Repo, sized bool, counted string) ([]git.RepoStatus, time.Time) {
+	// #nosec G304
+	data, err := os.ReadFile(filepath.Join(root, statusCacheFileName))
+	if err != nil {
+		return nil, time.Time{}
+	}
+	var cache statusCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, time.Time{}
+	}
+	if !slices.Equal(cache.Repos, statusCacheKey(repos)) || (sized && !cache.Sized) || (counted != "" && cache.Counted != counted) {
+		return nil, time.Time{}
+	}
+
+	statuses := make([]git.RepoStatus, len(cache.Statuses))
+	for i, c := range cache.Statuses {
+		statuses[i] = git.RepoStatus{
+			Name:             c.Name,
+			Branch:           c.Branch,
+			Status:           c.Status,
+			SyncState:        c.SyncState,
+			CommitCount:      c.CommitCount,
+			LastCommit:       c.LastCommit,
+			Size:             c.Size,
+			MismatchedRemote: c.MismatchedRemote,
+
+			DefaultBranchCommits: c.DefaultCommits,
+		}
+	}
+	return statuses, cache.Checked
+}
+
+// saveStatusCache stores the statuses of repos in the workspace at root. Statuses with
+// errors are left out, as they are usually transient (e.g., a failed fetch). Failures are
+// ignored, as the cache is only an optimization.
+func saveStatusCache(root string, repos []api.Repo, statuses []git.RepoStatus, sized bool, counted string) {
+	cache := statusCache{
+		Checked: time.Now(),
+		Repos:   statusCacheKey(repos),
+		Sized:   sized,
+		Counted: counted,
+	}
+	for _, s := range statuses {
+		if s.Error != nil || s.Name == "" {
+			continue
+		}
+		cache.Statuses = append(cache.Statuses, cachedStatus{
+			Name:             s.Name,
+			Branch:           s.Branch,
+			Status:           s.Status,
+			SyncState:        s.SyncState,
+			CommitCount:      s.CommitCount,
+			LastCommit:       s.LastCommit,
+			Size:             s.Size,
+			MismatchedRemote: s.MismatchedRemote,
+			DefaultCommits:   s.DefaultBranchCommits,
+		})
+	}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(filepath.Join(root, statusCacheFileName), data, 0o600)
+}
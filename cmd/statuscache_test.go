@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/liffiton/repoman/internal/api"
+	"github.com/liffiton/repoman/internal/git"
+)
+
+func TestStatusCache(t *testing.T) {
+	repos := []api.Repo{
+		{Name: "lab1-jsmith", URL: "https://github.com/jsmith/lab1"},
+		{Name: "lab1-adoe", URL: "https://github.com/adoe/lab1"},
+	}
+	lastCommit := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	ok := git.RepoStatus{
+		Name:                 "lab1-jsmith",
+		Branch:               "main",
+		Status:               "clean",
+		SyncState:            git.StateSynced,
+		CommitCount:          4,
+		LastCommit:           lastCommit,
+		Size:                 2048,
+		DefaultBranchCommits: 3,
+	}
+	failed := git.RepoStatus{Name: "lab1-adoe", Error: errors.New("fetch failed")}
+
+	tests := []struct {
+		name        string
+		saveSized   bool
+		saveCounted string
+		loadRepos   []api.Repo
+		loadSized   bool
+		loadCounted string
+		want        []git.RepoStatus
+	}{
+		{
+			name:      "round trip omits statuses with errors",
+			loadRepos: repos,
+			want:      []git.RepoStatus{ok},
+		},
+		{
+			name:      "repositories in another order",
+			loadRepos: []api.Repo{repos[1], repos[0]},
+			want:      []git.RepoStatus{ok},
+		},
+		{
+			name:      "repository added",
+			loadRepos: append(slices.Clone(repos), api.Repo{Name: "lab1-bchen", URL: "https://github.com/bchen/lab1"}),
+		},
+		{
+			name:      "repository URL changed",
+			loadRepos: []api.Repo{repos[0], {Name: "lab1-adoe", URL: "https://github.com/adoe/lab1-redo"}},
+		},
+		{
+			name:      "sizes missing",
+			loadRepos: repos,
+			loadSized: true,
+		},
+		{
+			name:      "sizes present",
+			saveSized: true,
+			loadRepos: repos,
+			loadSized: true,
+			want:      []git.RepoStatus{ok},
+		},
+		{
+			name:        "commits counted differently",
+			saveCounted: "first-parent=true,no-merges=false,starter=0",
+			loadRepos:   repos,
+			loadCounted: "first-parent=false,no-merges=false,starter=0",
+		},
+		{
+			name:        "commits counted the same way",
+			saveCounted: "first-parent=false,no-merges=false,starter=0",
+			loadRepos:   repos,
+			loadCounted: "first-parent=false,no-merges=false,starter=0",
+			want:        []git.RepoStatus{ok},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := os.MkdirTemp("", "repoman-status-cache-test-*")
+			if err != nil {
+				t.Fatalf("failed to create temp dir: %v", err)
+			}
+			defer func() { _ = os.RemoveAll(root) }()
+
+			saveStatusCache(root, repos, []git.RepoStatus{ok, failed}, tt.saveSized, tt.saveCounted)
+
+			got, checked := loadStatusCache(root, tt.loadRepos, tt.loadSized, tt.loadCounted)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadStatusCache() = %+v, want %+v", got, tt.want)
+			}
+			if tt.want == nil && !checked.IsZero() {
+				t.Errorf("expected no check time for a rejected cache, got %v", checked)
+			}
+			if tt.want != nil && time.Since(checked) > time.Minute {
+				t.Errorf("expected a recent check time, got %v", checked)
+			}
+		})
+	}
+
+	// A missing or unreadable cache gives no statuses.
+	root, err := os.MkdirTemp("", "repoman-status-cache-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(root) }()
+
+	if got, _ := loadStatusCache(root, repos, false, ""); got != nil {
+		t.Errorf("expected no statuses without a cache, got %+v", got)
+	}
+	if err := os.WriteFile(filepath.Join(root, statusCacheFileName), []byte("{not json"), 0o600); err != nil {
+		t.Fatalf("failed to write cache: %v", err)
+	}
+	if got, _ := loadStatusCache(root, repos, false, ""); got != nil {
+		t.Errorf("expected no statuses from a corrupt cache, got %+v", got)
+	}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/liffiton/repoman/internal/config"
+	"github.com/liffiton/repoman/internal/ui"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	addRefreshFlag(switchCmd)
+	rootCmd.AddCommand(switchCmd)
+}
+
+var switchCmd = &cobra.Command{
+	Use:   "switch",
+	Short: "Switch the workspace to a different assignment in the same course",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ui.PrintHeader("Switch Assignment")
+		pterm.Println()
+
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		wcfg, err := config.LoadWorkspace()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return errors.New("no workspace found. Run 'repoman init' first")
+			}
+			return fmt.Errorf("failed to load workspace: %w", err)
+		}
+
+		if err := checkNotInClone(wcfg.Root); err != nil {
+			return err
+		}
+
+		ui.Dim.Printf("Course: %s\n", wcfg.CourseName)
+		ui.Dim.Printf("Current assignment: %s\n", wcfg.AssignmentName)
+		pterm.Println()
+
+		client, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+
+		selected, err := selectAssignment(cmd.Context(), client, wcfg.CourseID, wcfg.AssignmentName)
+		if err != nil {
+			return err
+		}
+
+		if selected.ID == wcfg.AssignmentID {
+			fmt.Println("Assignment unchanged.")
+			return nil
+		}
+
+		// SaveWorkspace writes to the current directory, so save from the workspace root.
+		if err := os.Chdir(wcfg.Root); err != nil {
+			return fmt.Errorf("failed to change to workspace root: %w", err)
+		}
+
+		wcfg.AssignmentID = selected.ID
+		wcfg.AssignmentName = selected.Name
+		if err := wcfg.SaveWorkspace(); err != nil {
+			return fmt.Errorf("failed to save workspace config: %w", err)
+		}
+
+		ui.Success.Print("Workspace switched ")
+		fmt.Println("to " + pterm.Bold.Sprintf("%s - %s", wcfg.CourseName, wcfg.AssignmentName))
+		return nil
+	},
+}
@@ -1,18 +1,66 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"slices"
+	"strings"
 
+	"github.com/liffiton/repoman/internal/api"
+	"github.com/liffiton/repoman/internal/config"
 	"github.com/liffiton/repoman/internal/git"
 	"github.com/liffiton/repoman/internal/ui"
+	"github.com/liffiton/repoman/internal/webhook"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 )
 
-var useHTTP bool
+// defaultSyncConcurrency is the number of concurrent clones/pulls when not configured.
+const defaultSyncConcurrency = 6
+
+var (
+	useHTTP           bool
+	syncBranch        string
+	recurseSubmodules bool
+	skipLFS           bool
+	abortOnConflict   bool
+	printURLsOnError  bool
+	selectRepos       bool
+	fixRemotes        bool
+	allowFailures     bool
+	noHooks           bool
+	forcePull         bool
+	stashChanges      bool
+	partialClone      bool
+	pruneBranches     bool
+	concurrency       int
+)
 
 func init() {
-	syncCmd.Flags().BoolVar(&useHTTP, "http", false, "Use HTTP instead of SSH for git operations")
+	syncCmd.Flags().BoolVar(&useHTTP, "http", false, "Use HTTP instead of SSH for git operations (default from the workspace's use_http)")
+	syncCmd.Flags().StringVar(&syncBranch, "branch", "", "Clone and keep each repository on this branch instead of the default branch")
+	syncCmd.Flags().BoolVar(&recurseSubmodules, "recurse-submodules", false, "Also clone and update each repository's submodules")
+	syncCmd.Flags().BoolVar(&partialClone, "partial", false, "Make new clones partial clones, which download the contents of older files only when needed")
+	syncCmd.Flags().BoolVar(&skipLFS, "skip-lfs", false, "Do not download Git LFS content (leaves LFS pointer files in place)")
+	syncCmd.Flags().BoolVar(&abortOnConflict, "abort-on-conflict", false, "Abort the merge if a pull leaves a repository with merge conflicts")
+	syncCmd.Flags().BoolVar(&selectRepos, "select", false, "Choose which repositories to sync from a list, defaulting to the previous choice")
+	syncCmd.Flags().BoolVar(&forcePull, "force", false, "Pull into repositories with uncommitted local changes instead of skipping them")
+	syncCmd.Flags().BoolVar(&stashChanges, "stash", false, "Stash uncommitted local changes before pulling and reapply them afterward")
+	syncCmd.MarkFlagsMutuallyExclusive("force", "stash")
+	syncCmd.Flags().BoolVar(&pruneBranches, "prune-branches", false, "After pulling, delete local branches whose branch was deleted on the remote (keeping the current branch and any with unmerged commits)")
+	syncCmd.Flags().BoolVar(&fixRemotes, "fix-remotes", false, "Point clones whose origin differs from the assignment's URL at the new URL before pulling")
+	syncCmd.Flags().BoolVar(&allowFailures, "allow-failures", false, "Exit successfully even if some repositories fail to sync")
+	syncCmd.Flags().BoolVar(&noHooks, "no-hooks", false, "Do not run the workspace's post_sync_hook command")
+	syncCmd.Flags().BoolVar(&printURLsOnError, "print-urls-on-error", false, "Print the URL used for each repository that fails to sync")
+	syncCmd.Flags().IntVar(&concurrency, "concurrency", defaultSyncConcurrency, "Number of repositories to clone/pull concurrently")
+	addTimingsFlag(syncCmd)
+	addTimeoutFlag(syncCmd)
+	addRateLimitFlag(syncCmd)
+	addAssignmentFlags(syncCmd, "sync")
+	addReposFileFlag(syncCmd)
+	addRefreshFlag(syncCmd)
 	rootCmd.AddCommand(syncCmd)
 }
 
@@ -20,15 +68,28 @@ var syncCmd = &cobra.Command{
 	Use:   "sync",
 	Short: "Sync student repositories for the current assignment",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx, err := loadWorkspaceContext()
+		workers, err := resolveConcurrency(cmd, defaultSyncConcurrency)
 		if err != nil {
 			return err
 		}
+		if err := checkTimeoutFlag(cmd); err != nil {
+			return err
+		}
 
-		ui.PrintHeader(fmt.Sprintf("Syncing repositories for %s", pterm.Bold.Sprintf("%s - %s", ctx.Wcfg.CourseName, ctx.Wcfg.AssignmentName)))
+		ctx, err := loadWorkspaceContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+		applyUseHTTP(cmd, ctx.Wcfg)
+
+		ui.PrintHeader(fmt.Sprintf("Syncing repositories for %s", pterm.Bold.Sprint(assignmentTitle(ctx.Wcfg))))
 		if ctx.OrigDir != ctx.Wcfg.Root {
 			ui.Dim.Printf("Workspace: %s\n", ctx.Wcfg.Root)
 		}
+		workers, err = applyRateLimit(workers)
+		if err != nil {
+			return err
+		}
 		pterm.Println()
 
 		if len(ctx.Repos) == 0 {
@@ -36,36 +97,329 @@ var syncCmd = &cobra.Command{
 			return nil
 		}
 
-		bar, _ := ui.Progressbar.WithTotal(len(ctx.Repos)).Start()
+		for _, name := range ctx.Collisions {
+			ui.Warning.Printf("Several repositories are named %s; cloning each into a directory suffixed with its owner\n", name)
+		}
+
+		if selectRepos {
+			ctx.Repos, err = promptRepoSelection(ctx.Wcfg, ctx.Repos, !ctx.FromFlags)
+			if err != nil {
+				return err
+			}
+			if len(ctx.Repos) == 0 {
+				fmt.Println("No repositories selected.")
+				return nil
+			}
+			pterm.Println()
+		}
 
-		manager := git.NewManager(6)
+		bar := ui.StartProgress(len(ctx.Repos), "Syncing").WithTally()
+
+		pullLFS := !skipLFS && git.LFSAvailable()
+
+		manager := git.NewManagerWithOptions(git.WithConcurrency(workers), git.WithOpTimeout(opTimeout))
 		var gitRepos []git.RepoInfo
 		for _, r := range ctx.Repos {
 			gitRepos = append(gitRepos, git.RepoInfo{
-				Name:    r.Name,
-				URL:     r.URL,
-				Path:    r.Name, // Clone into current directory using the repo name
-				UseHTTP: useHTTP,
+				Name:              r.Name,
+				URL:               r.URL,
+				Path:              r.Name, // Clone into current directory using the repo name
+				UseHTTP:           useHTTP,
+				Branch:            syncBranch,
+				RecurseSubmodules: recurseSubmodules,
+				AbortOnConflict:   abortOnConflict,
+				PullLFS:           pullLFS,
+				FixRemote:         fixRemotes,
+				Force:             forcePull,
+				Stash:             stashChanges,
+				PartialClone:      partialClone,
 			})
 		}
 
-		errs := manager.SyncAllCtx(cmd.Context(), gitRepos, func() {
-			bar.Increment()
+		// Only new clones are partial, so note which repositories are cloned now.
+		var cloning []bool
+		if partialClone {
+			cloning = make([]bool, len(gitRepos))
+			for i, r := range gitRepos {
+				_, err := os.Stat(r.Path)
+				cloning[i] = os.IsNotExist(err)
+			}
+		}
+
+		errs, durations := manager.SyncAllTimedCtx(cmd.Context(), gitRepos, func(done git.RepoInfo, err error) {
+			bar.IncrementOutcome(done.Name, syncOutcome(err))
 		})
 
 		fmt.Println() // New line after progress bar
 
-		successCount := 0
+		if cmd.Context().Err() != nil {
+			return reportSyncCancelled(ctx.Repos, errs)
+		}
+
+		event := newWebhookEvent("sync", ctx.Wcfg)
+		successCount, skippedCount, emptyCount, conflictCount, mismatchCount, stashCount := 0, 0, 0, 0, 0, 0
 		for i, err := range errs {
+			// Skipped and empty repositories don't fail the sync, so they aren't failures here either.
+			result := webhook.RepoResult{Name: ctx.Repos[i].Name, OK: syncOutcome(err) != ui.OutcomeFailed}
 			if err != nil {
+				result.Error = err.Error()
+			}
+			event.Repos = append(event.Repos, result)
+
+			if errors.Is(err, git.ErrLocalChanges) {
+				ui.Warning.Printf("Skipped %s (local changes)\n", ctx.Repos[i].Name)
+				skippedCount++
+			} else if errors.Is(err, git.ErrEmptyRepo) {
+				// Students who haven't pushed yet are expected, not a failure.
+				ui.Dim.Printf("%s: empty (no commits yet)\n", ctx.Repos[i].Name)
+				emptyCount++
+			} else if err != nil {
 				ui.Error.Printf("Error syncing %s: %v\n", ctx.Repos[i].Name, err)
+				if errors.Is(err, git.ErrMergeConflict) && !abortOnConflict {
+					conflictCount++
+				}
+				if errors.Is(err, git.ErrRemoteMismatch) {
+					mismatchCount++
+				}
+				if errors.Is(err, git.ErrStashConflict) {
+					stashCount++
+				}
+				var syncErr *git.SyncError
+				if printURLsOnError && errors.As(err, &syncErr) {
+					ui.Dim.Printf("  URL: %s\n", syncErr.URL)
+				}
 			} else {
 				successCount++
 			}
 		}
 
-		fmt.Println(ui.Success.Sprint("Sync complete. ") + fmt.Sprintf("%d/%d repositories synced successfully.", successCount, len(ctx.Repos)))
+		waitWebhook := postWebhook(ctx.Wcfg, event)
+
+		if conflictCount > 0 {
+			ui.Warning.Printf("%d repositories have merge conflicts and will not be pulled until they are resolved. Resolve them, run 'git merge --abort' in each, or sync with --abort-on-conflict.\n", conflictCount)
+		}
+
+		if mismatchCount > 0 {
+			ui.Warning.Printf("%d repositories were not pulled because their origin differs from the assignment's URL (e.g., after an organization was renamed). Sync with --fix-remotes to update them.\n", mismatchCount)
+		}
+
+		if !skipLFS && !pullLFS {
+			warnMissingLFS(gitRepos)
+		}
+
+		if partialClone {
+			warnFullClones(gitRepos, cloning, errs)
+		}
+
+		if stashCount > 0 {
+			ui.Warning.Printf("%d repositories have local changes that could not be reapplied after pulling. The changes are kept in each repository's stash; see the errors above.\n", stashCount)
+		}
+
+		if skippedCount > 0 {
+			ui.Warning.Printf("%d repositories with uncommitted changes were not pulled. Commit or stash the changes, or sync with --stash or --force.\n", skippedCount)
+		}
+
+		summary := fmt.Sprintf("%d/%d repositories synced successfully.", successCount, len(ctx.Repos))
+		if emptyCount > 0 {
+			summary += fmt.Sprintf(" %d are empty.", emptyCount)
+		}
+		fmt.Println(ui.Success.Sprint("Sync complete. ") + summary)
+
+		if timings > 0 {
+			names := make([]string, len(gitRepos))
+			for i, r := range gitRepos {
+				names[i] = r.Name
+			}
+			printTimings(names, durations, timings)
+		}
+
+		var synced []git.RepoInfo
+		for i, err := range errs {
+			if err == nil {
+				synced = append(synced, gitRepos[i])
+			}
+		}
+
+		if pruneBranches {
+			pruneSyncedBranches(cmd.Context(), manager, synced)
+		}
 
+		hookFailures := 0
+		if ctx.Wcfg.PostSyncHook != "" && !noHooks {
+			hookFailures = runPostSyncHook(cmd.Context(), manager, synced, ctx.Wcfg.PostSyncHook)
+		}
+
+		waitWebhook()
+
+		if failed := len(ctx.Repos) - successCount - skippedCount - emptyCount; failed > 0 && !allowFailures {
+			return fmt.Errorf("%d of %d repositories failed to sync", failed, len(ctx.Repos))
+		}
+		if hookFailures > 0 && !allowFailures {
+			return fmt.Errorf("post-sync hook failed in %d of %d repositories", hookFailures, successCount)
+		}
 		return nil
 	},
 }
+
+// syncOutcome classifies a repository's sync result for the progress bar. Repositories
+// left alone because of local changes or because they have no commits yet are skipped.
+func syncOutcome(err error) ui.Outcome {
+	switch {
+	case err == nil:
+		return ui.OutcomeSucceeded
+	case errors.Is(err, git.ErrLocalChanges), errors.Is(err, git.ErrEmptyRepo):
+		return ui.OutcomeSkipped
+	default:
+		return ui.OutcomeFailed
+	}
+}
+
+// pruneSyncedBranches deletes stale branches in each of the synced repositories, listing
+// the branches pruned in each. Failures are reported but don't fail the sync.
+func pruneSyncedBranches(ctx context.Context, manager *git.Manager, synced []git.RepoInfo) {
+	if len(synced) == 0 {
+		return
+	}
+
+	results := manager.PruneBranchesAllCtx(ctx, synced, nil)
+	total := 0
+	for _, r := range results {
+		if r.Error != nil {
+			ui.Warning.Printf("Could not prune branches in %s: %v\n", r.Name, r.Error)
+			continue
+		}
+		if len(r.Pruned) > 0 {
+			ui.Dim.Printf("Pruned %d branches in %s: %s\n", len(r.Pruned), r.Name, strings.Join(r.Pruned, ", "))
+			total += len(r.Pruned)
+		}
+	}
+	fmt.Printf("Pruned %d stale branches in %d repositories.\n", total, len(synced))
+}
+
+// runPostSyncHook runs the workspace's post-sync hook command in each of the synced
+// repositories, printing the output of any that fail, and returns the number of failures.
+func runPostSyncHook(ctx context.Context, manager *git.Manager, synced []git.RepoInfo, hook string) int {
+	if len(synced) == 0 {
+		return 0
+	}
+
+	pterm.Println()
+	bar := ui.StartProgress(len(synced), "Running post-sync hook")
+	results := manager.RunHookAllCtx(ctx, synced, hook, func(done git.RepoInfo, err error) {
+		bar.IncrementItem(done.Name, err != nil)
+	})
+	fmt.Println() // New line after progress bar
+
+	failures := 0
+	for i, r := range results {
+		if r.Error == nil {
+			continue
+		}
+		failures++
+		ui.Error.Printf("Post-sync hook failed for %s: %v\n", synced[i].Name, r.Error)
+		for _, line := range strings.Split(r.Output, "\n") {
+			if line != "" {
+				ui.Dim.Printf("  %s\n", line)
+			}
+		}
+	}
+	if failures > 0 {
+		ui.Warning.Printf("Post-sync hook failed in %d of %d repositories.\n", failures, len(synced))
+	} else {
+		ui.Success.Printf("Post-sync hook ran in %d repositories.\n", len(synced))
+	}
+	return failures
+}
+
+// promptRepoSelection asks the user which of repos to sync, checking those chosen last
+// time (or all of them the first time). If save is true, a non-empty choice is recorded
+// in the workspace config.
+func promptRepoSelection(wcfg *config.WorkspaceConfig, repos []api.Repo, save bool) ([]api.Repo, error) {
+	names := make([]string, len(repos))
+	for i, r := range repos {
+		names[i] = r.Name
+	}
+	defaults := names
+	if len(wcfg.SyncSelection) > 0 {
+		defaults = nil
+		for _, name := range wcfg.SyncSelection {
+			if slices.Contains(names, name) {
+				defaults = append(defaults, name)
+			}
+		}
+	}
+
+	chosen, err := pterm.DefaultInteractiveMultiselect.
+		WithDefaultText("Select repositories to sync (Enter to toggle, Tab to confirm)").
+		WithOptions(names).
+		WithDefaultOptions(defaults).
+		WithMaxHeight(15).
+		Show()
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []api.Repo
+	for _, r := range repos {
+		if slices.Contains(chosen, r.Name) {
+			selected = append(selected, r)
+		}
+	}
+
+	// An empty choice is not saved, so that the next --select starts with all checked.
+	if save && len(chosen) > 0 {
+		wcfg.SyncSelection = chosen
+		if err := wcfg.SaveWorkspace(); err != nil {
+			ui.Warning.Printf("Could not save the selection: %v\n", err)
+		}
+	}
+	return selected, nil
+}
+
+// warnMissingLFS prints a single warning if any of the repositories use Git LFS, which
+// could not be downloaded because git-lfs is not installed.
+func warnMissingLFS(repos []git.RepoInfo) {
+	count := 0
+	for _, r := range repos {
+		if git.UsesLFS(r.Path) {
+			count++
+		}
+	}
+	if count > 0 {
+		ui.Warning.Printf("%d repositories use Git LFS, but git-lfs is not installed; they contain LFS pointer files instead of the actual content. Install git-lfs and sync again, or pass --skip-lfs to silence this warning.\n", count)
+	}
+}
+
+// warnFullClones prints a single warning if any of the repositories newly cloned with
+// --partial (those marked in cloning) were cloned in full, as their Git host doesn't
+// support partial clone.
+func warnFullClones(repos []git.RepoInfo, cloning []bool, errs []error) {
+	count := 0
+	for i, r := range repos {
+		if cloning[i] && errs[i] == nil && !git.IsPartialClone(r.Path) {
+			count++
+		}
+	}
+	if count > 0 {
+		ui.Warning.Printf("%d repositories were cloned in full because their Git host does not support partial clone.\n", count)
+	}
+}
+
+// reportSyncCancelled reports the repositories that failed before a sync was interrupted,
+// followed by how many had completed. The repositories that were interrupted or never
+// started are not listed individually.
+func reportSyncCancelled(repos []api.Repo, errs []error) error {
+	completed := 0
+	for i, err := range errs {
+		switch {
+		case errors.Is(err, context.Canceled):
+		case err != nil:
+			completed++
+			ui.Error.Printf("Error syncing %s: %v\n", repos[i].Name, err)
+		default:
+			completed++
+		}
+	}
+	ui.Warning.Printf("Sync cancelled. %d of %d repositories completed.\n", completed, len(repos))
+	return errors.New("interrupted")
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/liffiton/repoman/internal/git"
+	"github.com/liffiton/repoman/internal/ui"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// defaultTagConcurrency is the number of concurrent tags/pushes when not configured.
+const defaultTagConcurrency = 6
+
+var (
+	tagMessage string
+	tagPush    bool
+)
+
+func init() {
+	tagCmd.Flags().StringVarP(&tagMessage, "message", "m", "", "Create annotated tags with this message (default: lightweight tags)")
+	tagCmd.Flags().BoolVar(&tagPush, "push", false, "Push each new tag to the repository's origin")
+	tagCmd.Flags().IntVar(&concurrency, "concurrency", defaultTagConcurrency, "Number of repositories to tag concurrently")
+	addRefreshFlag(tagCmd)
+	rootCmd.AddCommand(tagCmd)
+}
+
+var tagCmd = &cobra.Command{
+	Use:   "tag <name>",
+	Short: "Tag the current commit in each cloned repository",
+	Long: `Tag the current commit (HEAD) in each cloned repository, e.g., to record exactly what
+each student had at a deadline. Run 'repoman sync' first so that each clone is up to date.
+
+Repositories that already have a tag with the name are reported and left unchanged. With
+--push, each new tag is pushed to the repository's origin.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if name == "" {
+			return errors.New("tag name must not be empty")
+		}
+
+		workers, err := resolveConcurrency(cmd, defaultTagConcurrency)
+		if err != nil {
+			return err
+		}
+
+		ctx, err := loadWorkspaceContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		ui.PrintHeader(fmt.Sprintf("Tagging %s for %s", pterm.Bold.Sprint(name), pterm.Bold.Sprint(assignmentTitle(ctx.Wcfg))))
+		if ctx.OrigDir != ctx.Wcfg.Root {
+			ui.Dim.Printf("Workspace: %s\n", ctx.Wcfg.Root)
+		}
+		pterm.Println()
+
+		// Only repositories that have been cloned can be tagged.
+		var gitRepos []git.RepoInfo
+		for _, r := range ctx.Repos {
+			if _, err := os.Stat(r.Name); err == nil {
+				gitRepos = append(gitRepos, git.RepoInfo{
+					Name: r.Name,
+					Path: r.Name,
+				})
+			}
+		}
+
+		if len(gitRepos) == 0 {
+			fmt.Println("No cloned repositories found. Run 'repoman sync' first.")
+			return nil
+		}
+
+		bar := ui.StartProgress(len(gitRepos), "Tagging")
+
+		manager := git.NewManager(workers)
+		results := manager.TagAllCtx(cmd.Context(), gitRepos, name, tagMessage, tagPush, func() {
+			bar.Increment()
+		})
+
+		fmt.Println() // New line after progress bar
+
+		tagged, existing, failed := 0, 0, 0
+		for _, r := range results {
+			switch {
+			case errors.Is(r.Error, git.ErrTagExists):
+				existing++
+				ui.Warning.Printf("%s already has a tag named %s; left unchanged\n", r.Name, name)
+			case r.Error != nil && r.Tagged:
+				failed++
+				ui.Error.Printf("Tagged %s, but failed to push the tag: %v\n", r.Name, r.Error)
+			case r.Error != nil:
+				failed++
+				ui.Error.Printf("Error tagging %s: %v\n", r.Name, r.Error)
+			default:
+				tagged++
+			}
+		}
+
+		verb := "tagged"
+		if tagPush {
+			verb = "tagged and pushed"
+		}
+		fmt.Println(ui.Success.Sprint("Tag complete. ") + fmt.Sprintf("%d/%d repositories %s, %d already tagged.", tagged, len(gitRepos), verb, existing))
+
+		if failed+existing > 0 {
+			return fmt.Errorf("%d of %d repositories were not tagged", failed+existing, len(gitRepos))
+		}
+		return nil
+	},
+}
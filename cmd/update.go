@@ -1,14 +1,29 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/liffiton/repoman/internal/config"
 	"github.com/liffiton/repoman/internal/ui"
 	"github.com/liffiton/repoman/internal/update"
+	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 )
 
+var (
+	checkOnly     bool
+	updateChannel string
+)
+
 func init() {
+	updateCmd.Flags().BoolVar(&checkOnly, "check-only", false, "Only check whether an update is available; do not download or install it")
+	updateCmd.Flags().StringVar(&updateChannel, "channel", "", "Release channel to update from: stable or beta (default from config, else stable)")
+	_ = updateCmd.RegisterFlagCompletionFunc("channel", cobra.FixedCompletions(
+		[]string{string(update.ChannelStable), string(update.ChannelBeta)},
+		cobra.ShellCompDirectiveNoFileComp,
+	))
 	rootCmd.AddCommand(updateCmd)
 }
 
@@ -16,21 +31,59 @@ var updateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "Update repoman to the latest version",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		name := cfg.UpdateChannel
+		if cmd.Flags().Changed("channel") {
+			name = updateChannel
+		}
+		channel, err := update.ParseChannel(name)
+		if err != nil {
+			return err
+		}
+
 		ui.PrintHeader("Checking for updates...")
 		fmt.Println()
 
-		updated, err := update.CheckAndUpdate(version)
+		token := cfg.GetGitHubToken()
+		release, hasUpdate, err := update.Check(version, channel, token)
+		if errors.Is(err, update.ErrDevBuild) {
+			fmt.Println("Skipping update: this is a development build of repoman. Install a release to enable updates.")
+			return nil
+		}
+		if errors.Is(err, update.ErrRateLimited) && token == "" {
+			return fmt.Errorf("%w; set %s to a GitHub token to raise the limit", err, config.GitHubTokenEnvVar)
+		}
 		if err != nil {
 			return err
 		}
 
-		if updated {
-			fmt.Println()
-			ui.Success.Print("Successfully updated ")
-			fmt.Println("to the latest version.")
-		} else {
+		if !hasUpdate {
 			fmt.Println("Repoman is already up to date.")
+			return nil
 		}
+
+		if checkOnly {
+			ui.Info.Printf("Update available: %s ", pterm.Bold.Sprint(release.TagName))
+			ui.Dim.Printf("(current: %s)\n", version)
+			if notes := strings.TrimSpace(release.Body); notes != "" {
+				fmt.Println()
+				fmt.Println(notes)
+			}
+			if release.HTMLURL != "" {
+				fmt.Println()
+				ui.Dim.Println(release.HTMLURL)
+			}
+			fmt.Println()
+			fmt.Println("Run 'repoman update' to install it.")
+			return nil
+		}
+
+		if err := update.Apply(release); err != nil {
+			return err
+		}
+
+		fmt.Println()
+		ui.Success.Print("Successfully updated ")
+		fmt.Printf("to %s.\n", release.TagName)
 		return nil
 	},
 }
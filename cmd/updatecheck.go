@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/liffiton/repoman/internal/config"
+	"github.com/liffiton/repoman/internal/ui"
+	"github.com/liffiton/repoman/internal/update"
+	"github.com/spf13/cobra"
+)
+
+// updateCheckTimeout bounds the background update check so it never outlives a command
+// by much; if it hasn't finished when the command does, the notice is simply skipped.
+const updateCheckTimeout = 5 * time.Second
+
+var (
+	noUpdateCheck bool
+
+	// updateNotice receives the tag of a newer release from the background update check.
+	// It is nil when no check was started.
+	updateNotice chan string
+)
+
+// startUpdateCheck starts a background check for a newer release if automatic checks
+// are enabled and the configured interval has passed since the last one. Any failure,
+// including an invalid configuration, silently skips the check.
+func startUpdateCheck(cmd *cobra.Command) {
+	if noUpdateCheck || cmd == updateCmd || version == "dev" {
+		return
+	}
+
+	interval, err := cfg.GetUpdateCheckInterval()
+	if err != nil || interval == 0 {
+		return
+	}
+	if time.Since(config.LastUpdateCheck()) < interval {
+		return
+	}
+	channel, err := update.ParseChannel(cfg.UpdateChannel)
+	if err != nil {
+		return
+	}
+
+	updateNotice = make(chan string, 1)
+	token := cfg.GetGitHubToken()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+		defer cancel()
+
+		release, hasUpdate, err := update.CheckCtx(ctx, version, channel, token)
+		if err != nil {
+			return
+		}
+		_ = config.RecordUpdateCheck(time.Now())
+		if hasUpdate {
+			updateNotice <- release.TagName
+		}
+	}()
+}
+
+// printUpdateNotice prints a one-line notice to stderr if the background update check
+// has found a newer release. It never waits for a check that is still running.
+func printUpdateNotice() {
+	select {
+	case tag := <-updateNotice:
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, ui.Info.Sprintf("Repoman %s is available (current: %s). Run 'repoman update' to install it.", tag, version))
+	default:
+	}
+}
@@ -1,65 +1,549 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/fs"
+	"log"
+	"math"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/liffiton/repoman/internal/api"
 	"github.com/liffiton/repoman/internal/config"
+	"github.com/liffiton/repoman/internal/git"
+	"github.com/liffiton/repoman/internal/ui"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
 )
 
 // requireAuth ensures the user is authenticated.
 func requireAuth() error {
-	if cfg.APIKey == "" {
+	if cfg.GetAPIKey() == "" {
 		return errors.New("not authenticated. Run 'repoman auth' first")
 	}
 	return nil
 }
 
+// confirm asks the user a yes/no question, defaulting to no. With --yes, the question is
+// printed along with the answer instead, so that the output still records what was agreed to.
+func confirm(question string) bool {
+	if assumeYes {
+		ui.Info.Printf("%s yes (--yes)\n", question)
+		return true
+	}
+	result, _ := pterm.DefaultInteractiveConfirm.WithDefaultText(question).WithDefaultValue(false).Show()
+	return result
+}
+
+// refresh bypasses cached API responses when set (--refresh).
+var refresh bool
+
+// addRefreshFlag registers the --refresh flag on a command that fetches data from the server.
+func addRefreshFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&refresh, "refresh", false, "Bypass cached server responses and fetch fresh data")
+}
+
+// courseFlag and assignmentFlag select an assignment by ID or name (--course and
+// --assignment) instead of using the workspace's.
+var courseFlag, assignmentFlag string
+
+// addAssignmentFlags registers the --course and --assignment flags on a command that
+// can run without a workspace.
+func addAssignmentFlags(cmd *cobra.Command, verb string) {
+	cmd.Flags().StringVar(&courseFlag, "course", "", "Course ID or name (narrows the search for --assignment)")
+	cmd.Flags().StringVar(&assignmentFlag, "assignment", "", "Assignment ID or name to "+verb+" instead of the workspace's")
+}
+
+// reposFile is a file listing the repositories to use instead of the server's (--repos-file).
+var reposFile string
+
+// applyUseHTTP sets useHTTP from the workspace's use_http preference unless the command's
+// --http flag was given, which overrides it either way.
+func applyUseHTTP(cmd *cobra.Command, wcfg *config.WorkspaceConfig) {
+	if !cmd.Flags().Changed("http") {
+		useHTTP = wcfg.UseHTTP
+	}
+}
+
+// addReposFileFlag registers the --repos-file flag on a command that takes the assignment
+// flags (see addAssignmentFlags), which it excludes.
+func addReposFileFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&reposFile, "repos-file", "", "Read the repositories from a file (JSON, or one \"name url\" per line) instead of the server")
+	_ = cmd.MarkFlagFilename("repos-file")
+	cmd.MarkFlagsMutuallyExclusive("repos-file", "assignment")
+	cmd.MarkFlagsMutuallyExclusive("repos-file", "course")
+}
+
+// resolveAssignmentFlags looks up the assignment given by --assignment (and --course, if
+// set), each an ID or a name. Without --course, every course is searched. A name shared by
+// several courses or assignments is an error; use the ID instead. An assignment that isn't
+// found in any course is taken to be an ID, with its course unknown.
+// It returns a workspace config with the course and assignment set and no root.
+func resolveAssignmentFlags(ctx context.Context, client *api.Client) (*config.WorkspaceConfig, error) {
+	courses, err := client.GetCoursesCtx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch courses: %w", err)
+	}
+	if courseFlag != "" {
+		var matches []api.Course
+		for _, c := range courses {
+			if c.ID == courseFlag {
+				matches = []api.Course{c}
+				break
+			}
+			if c.Name == courseFlag {
+				matches = append(matches, c)
+			}
+		}
+		switch {
+		case len(matches) == 0:
+			return nil, fmt.Errorf("course %q not found", courseFlag)
+		case len(matches) > 1:
+			return nil, fmt.Errorf("course name %q is ambiguous; use its ID instead (%s, %s, ...)", courseFlag, matches[0].ID, matches[1].ID)
+		}
+		courses = matches
+	}
+
+	var matches []*config.WorkspaceConfig
+	for _, c := range courses {
+		assignments, err := client.GetAssignmentsCtx(ctx, c.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch assignments: %w", err)
+		}
+		for _, a := range assignments {
+			found := &config.WorkspaceConfig{CourseID: c.ID, CourseName: c.Name, AssignmentID: a.ID, AssignmentName: a.Name}
+			if a.ID == assignmentFlag {
+				return found, nil
+			}
+			if a.Name == assignmentFlag {
+				matches = append(matches, found)
+			}
+		}
+	}
+
+	switch {
+	case len(matches) == 1:
+		return matches[0], nil
+	case len(matches) > 1:
+		var ids []string
+		for _, m := range matches {
+			ids = append(ids, fmt.Sprintf("%s in %s", m.AssignmentID, m.CourseName))
+		}
+		return nil, fmt.Errorf("assignment name %q is ambiguous; use its ID instead (%s)", assignmentFlag, strings.Join(ids, ", "))
+	case courseFlag != "":
+		return nil, fmt.Errorf("assignment %q not found in course %s", assignmentFlag, courses[0].Name)
+	}
+	return &config.WorkspaceConfig{AssignmentID: assignmentFlag}, nil
+}
+
+// assignmentTitle returns the course and assignment names of a workspace for display,
+// or just the assignment ID if they are unknown.
+func assignmentTitle(wcfg *config.WorkspaceConfig) string {
+	if wcfg.AssignmentID == "" {
+		// Repositories from --repos-file outside a workspace.
+		return wcfg.AssignmentName
+	}
+	if wcfg.CourseName == "" {
+		return "assignment " + wcfg.AssignmentID
+	}
+	return fmt.Sprintf("%s - %s", wcfg.CourseName, wcfg.AssignmentName)
+}
+
+// timings is the number of slowest repositories to report after a command (--timings),
+// or 0 to report none.
+var timings int
+
+// defaultTimings is the number of repositories --timings reports when given no value.
+const defaultTimings = 5
+
+// addTimingsFlag registers the --timings flag on a command that reports per-repository
+// durations.
+func addTimingsFlag(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&timings, "timings", 0, fmt.Sprintf("Print the N slowest repositories after completion (--timings=N; default %d)", defaultTimings))
+	cmd.Flags().Lookup("timings").NoOptDefVal = strconv.Itoa(defaultTimings)
+}
+
+// opTimeout is the deadline for each network operation (--timeout), or zero for the defaults.
+var opTimeout time.Duration
+
+// addTimeoutFlag registers the --timeout flag on a command that clones, pulls, or fetches.
+func addTimeoutFlag(cmd *cobra.Command) {
+	cmd.Flags().DurationVar(&opTimeout, "timeout", 0, "Deadline for each clone, pull, or fetch, e.g. 30s or 10m (default none for sync, 2m for status)")
+}
+
+// checkTimeoutFlag returns an error if --timeout was given a value that is not positive.
+func checkTimeoutFlag(cmd *cobra.Command) error {
+	if cmd.Flags().Changed("timeout") && opTimeout <= 0 {
+		return fmt.Errorf("--timeout must be positive, got %s", opTimeout)
+	}
+	return nil
+}
+
+// rateLimit is the approximate download rate to stay under (--rate-limit), or empty for none.
+var rateLimit string
+
+// perOperationRate is the download rate assumed for each clone or pull when limiting the
+// total rate. Git can't throttle its own transfers, so --rate-limit only limits how many
+// run at once, and a single fast transfer can still exceed the limit.
+const perOperationRate = 1 << 20 // 1 MiB/s
+
+// addRateLimitFlag registers the --rate-limit flag on a command that clones or pulls.
+func addRateLimitFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&rateLimit, "rate-limit", "", "Approximate total download rate to stay under, in bytes/s (e.g., 4M or 500K) by running fewer operations at once")
+}
+
+// applyRateLimit returns the number of concurrent clones or pulls to run, at most workers,
+// to stay under --rate-limit if it is set, assuming each uses perOperationRate.
+func applyRateLimit(workers int) (int, error) {
+	if rateLimit == "" {
+		return workers, nil
+	}
+	rate, err := parseRate(rateLimit)
+	if err != nil {
+		return 0, err
+	}
+	limited := int(min(int64(workers), max(1, rate/perOperationRate)))
+	ui.Dim.Printf("Rate limit %s/s: running at most %d operations at once (Git can't throttle each transfer, so this is approximate).\n", formatBytes(rate), limited)
+	return limited, nil
+}
+
+// parseRate parses a --rate-limit value: a number of bytes per second with an optional
+// binary unit suffix K, M, or G (e.g., "500K" or "1.5M"), which may be followed by "B" or
+// "iB", and then "/s".
+func parseRate(value string) (int64, error) {
+	s := strings.TrimSuffix(strings.TrimSpace(value), "/s")
+	s, binary := strings.CutSuffix(s, "iB")
+	if !binary {
+		s = strings.TrimSuffix(s, "B")
+	}
+	multiplier := 1.0
+	if n := len(s); n > 0 {
+		switch strings.ToUpper(s[n-1:]) {
+		case "K":
+			multiplier = 1 << 10
+		case "M":
+			multiplier = 1 << 20
+		case "G":
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			s = s[:n-1]
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	rate := n * multiplier
+	// "iB" needs a unit before it, and ParseFloat accepts "NaN" and "Inf".
+	if err != nil || (binary && multiplier == 1) || math.IsNaN(rate) || rate < 1 || rate >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid --rate-limit %q: must be a positive rate such as 500K or 4M (bytes per second)", value)
+	}
+	return int64(rate), nil
+}
+
+// printTimings prints the n slowest of the named repositories, slowest first, along with
+// the time each took. Repositories with no recorded duration are skipped.
+func printTimings(names []string, durations []time.Duration, n int) {
+	type timing struct {
+		name     string
+		duration time.Duration
+	}
+	var all []timing
+	for i, d := range durations {
+		if d > 0 {
+			all = append(all, timing{names[i], d})
+		}
+	}
+	if n <= 0 || len(all) == 0 {
+		return
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].duration > all[j].duration })
+	if n > len(all) {
+		n = len(all)
+	}
+
+	pterm.Println()
+	pterm.Println(pterm.Bold.Sprintf("Slowest %d repositories:", n))
+	for _, t := range all[:n] {
+		fmt.Printf("  %8s  %s\n", t.duration.Round(10*time.Millisecond), t.name)
+	}
+}
+
+// newAPIClient creates an API client for the configured base URL and API key,
+// identifying itself with the repoman version and caching responses on disk.
+func newAPIClient() (*api.Client, error) {
+	client, err := api.NewClient(cfg.GetBaseURL(), cfg.GetAPIKey())
+	if err != nil {
+		return nil, err
+	}
+	client.SetUserAgent("repoman/" + version)
+	proxyURL, err := cfg.GetProxy()
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL != nil {
+		if err := client.SetProxy(proxyURL); err != nil {
+			return nil, err
+		}
+	}
+	if ui.IsVerbose() {
+		client.SetLogger(log.New(os.Stderr, "[api] ", log.Ltime))
+	}
+	if structuredLogger != nil {
+		client.SetStructuredLogger(structuredLogger)
+	}
+
+	ttl, err := cfg.GetCacheTTL()
+	if err != nil {
+		return nil, err
+	}
+	if ttl > 0 {
+		cacheDir, err := config.GetCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		client.SetCache(&api.Cache{Dir: cacheDir, TTL: ttl, Refresh: refresh})
+	}
+	return client, nil
+}
+
+// resolveConcurrency returns the number of concurrent git operations to use for a command.
+// The --concurrency flag takes precedence over the config file, which takes precedence
+// over the command's default.
+func resolveConcurrency(cmd *cobra.Command, defaultValue int) (int, error) {
+	n := defaultValue
+	if cfg.Concurrency != 0 {
+		n = cfg.Concurrency
+	}
+	if cmd.Flags().Changed("concurrency") {
+		n = concurrency
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("concurrency must be a positive integer, got %d", n)
+	}
+	return n, nil
+}
+
+// checkNotInClone returns an error if the current directory is inside one of the
+// repositories cloned into the workspace at root rather than in the workspace itself.
+func checkNotInClone(root string) error {
+	clone, err := config.FindEnclosingClone(root)
+	if err != nil {
+		return fmt.Errorf("failed to check current directory: %w", err)
+	}
+	if clone != "" {
+		return fmt.Errorf("you're inside a cloned repository (%s); cd to the workspace root (%s) and try again", clone, root)
+	}
+	return nil
+}
+
+// formatBytes formats a byte count using binary units (e.g., "1.5 MiB").
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 // workspaceContext holds the context for a workspace-related command.
 type workspaceContext struct {
 	Wcfg    *config.WorkspaceConfig
 	OrigDir string
 	Repos   []api.Repo
+	// Collisions lists repository names shared by several repositories, which were
+	// renamed to give each its own directory.
+	Collisions []string
+	// FromFlags is set if the assignment came from --assignment rather than a workspace
+	// config file, in which case Wcfg is not saved and Root is the current directory.
+	FromFlags bool
 }
 
 // loadWorkspaceContext loads the workspace configuration, changes to the root directory,
-// and fetches the assignment repositories.
-func loadWorkspaceContext() (*workspaceContext, error) {
+// and fetches the assignment repositories. If --assignment is set, no workspace is
+// loaded: the assignment is looked up with the API and the current directory is used.
+// If --repos-file is set, the repositories are read from it instead (see
+// loadReposFileContext).
+// Uses the provided context for timeout/cancellation control of the API request.
+func loadWorkspaceContext(ctx context.Context) (*workspaceContext, error) {
+	// Check this first, as a deleted current directory (e.g., a removed workspace) would
+	// otherwise be reported as having no workspace.
+	origDir, err := os.Getwd()
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, errors.New("the current directory no longer exists; cd to an existing directory and try again")
+		}
+		return nil, fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	if reposFile != "" {
+		return loadReposFileContext(origDir)
+	}
 	if err := requireAuth(); err != nil {
 		return nil, err
 	}
 
-	wcfg, err := config.LoadWorkspace()
+	client, err := newAPIClient()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, errors.New("no workspace found. Run 'repoman init' first")
+		return nil, err
+	}
+
+	var wcfg *config.WorkspaceConfig
+	fromFlags := assignmentFlag != ""
+	if fromFlags {
+		// The flags win over any workspace, which is not loaded at all.
+		wcfg, err = resolveAssignmentFlags(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		wcfg.Root = origDir
+	} else {
+		if courseFlag != "" {
+			return nil, errors.New("--course requires --assignment")
+		}
+		wcfg, err = loadWorkspaceRoot(origDir)
+		if err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("failed to load workspace: %w", err)
 	}
 
-	origDir, err := os.Getwd()
+	sshKey, err := wcfg.GetSSHKey(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get current directory: %w", err)
+		return nil, err
 	}
+	git.SetSSHKey(sshKey)
 
-	if err := os.Chdir(wcfg.Root); err != nil {
-		return nil, fmt.Errorf("failed to change to workspace root: %w", err)
+	repos, err := client.GetAssignmentReposCtx(ctx, wcfg.AssignmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch repositories: %w", err)
 	}
+	setGitToken(repos)
+	return newWorkspaceContext(wcfg, repos, origDir, fromFlags)
+}
 
-	client, err := api.NewClient(cfg.GetBaseURL(), cfg.APIKey)
+// loadReposFileContext loads the workspace context for --repos-file, without the server:
+// the repositories are read from the file, and the workspace is used if there is one.
+// Otherwise, the current directory is used as with --assignment, and the file's name
+// stands in for the assignment's.
+func loadReposFileContext(origDir string) (*workspaceContext, error) {
+	repos, err := api.LoadRepoList(reposFile)
 	if err != nil {
 		return nil, err
 	}
-	repos, err := client.GetAssignmentRepos(wcfg.AssignmentID)
+
+	var wcfg *config.WorkspaceConfig
+	_, err = config.FindWorkspaceRoot()
+	noWorkspace := errors.Is(err, os.ErrNotExist)
+	if noWorkspace {
+		wcfg = &config.WorkspaceConfig{AssignmentName: filepath.Base(reposFile), Root: origDir}
+	} else {
+		wcfg, err = loadWorkspaceRoot(origDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sshKey, err := wcfg.GetSSHKey(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch repositories: %w", err)
+		return nil, err
 	}
+	git.SetSSHKey(sshKey)
+	setGitToken(repos)
+	return newWorkspaceContext(wcfg, repos, origDir, noWorkspace)
+}
+
+// setGitToken has git authenticate with the configured access token, if any, to the hosts
+// of repos only, so that it isn't offered to any other server a repository refers to.
+func setGitToken(repos []api.Repo) {
+	var hosts []string
+	for _, r := range repos {
+		if host := git.HTTPHost(r.URL); host != "" && !slices.Contains(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	git.SetHTTPToken(cfg.GetGitToken(), hosts...)
+}
+
+// newWorkspaceContext returns the context for the workspace wcfg and its repositories,
+// renaming them for the workspace's layout and to avoid collisions.
+func newWorkspaceContext(wcfg *config.WorkspaceConfig, repos []api.Repo, origDir string, fromFlags bool) (*workspaceContext, error) {
+	if err := applyLayout(repos, wcfg.Layout); err != nil {
+		return nil, err
+	}
+	collisions := api.DisambiguateNames(repos)
 
 	return &workspaceContext{
-		Wcfg:    wcfg,
-		Repos:   repos,
-		OrigDir: origDir,
+		Wcfg:       wcfg,
+		Repos:      repos,
+		Collisions: collisions,
+		OrigDir:    origDir,
+		FromFlags:  fromFlags,
 	}, nil
 }
+
+// loadWorkspaceRoot loads the workspace configuration and changes to its root directory.
+// origDir is the current directory, used as the root if none is recorded.
+func loadWorkspaceRoot(origDir string) (*config.WorkspaceConfig, error) {
+	wcfg, err := config.LoadWorkspace()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New("no workspace found. Run 'repoman init' first")
+		}
+		return nil, fmt.Errorf("failed to load workspace: %w", err)
+	}
+
+	if wcfg.Root == "" {
+		// LoadWorkspace records the root for older configs, but if it is still unknown,
+		// assume the workspace is the current directory.
+		wcfg.Root = origDir
+	}
+
+	if _, err := os.Stat(wcfg.Root); errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("workspace root %s no longer exists; check that it was not moved or deleted, or run 'repoman init' in a new directory", wcfg.Root)
+	}
+
+	if err := checkNotInClone(wcfg.Root); err != nil {
+		return nil, err
+	}
+
+	if err := os.Chdir(wcfg.Root); err != nil {
+		return nil, fmt.Errorf("failed to change to workspace root: %w", err)
+	}
+	return wcfg, nil
+}
+
+// applyLayout renames repositories to the paths of their clones under the given directory
+// layout (see config.LayoutFlat). Repositories without an owner in their URL keep their
+// bare names. It returns an error if an owner would put a clone outside the workspace.
+func applyLayout(repos []api.Repo, layout string) error {
+	sep := ""
+	switch layout {
+	case config.LayoutOwner:
+		sep = "-"
+	case config.LayoutNested:
+		sep = "/"
+	default:
+		return nil
+	}
+	for i, r := range repos {
+		if owner := r.Owner(); owner != "" {
+			name := owner + sep + r.Name
+			// Each repository is cloned into a directory named after it.
+			if !filepath.IsLocal(name) {
+				return fmt.Errorf("invalid repository name %q: must be a relative path within the workspace", name)
+			}
+			repos[i].Name = name
+		}
+	}
+	return nil
+}
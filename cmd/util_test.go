@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/liffiton/repoman/internal/api"
+	"github.com/liffiton/repoman/internal/config"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"1000", 1000, false},
+		{"500K", 500 << 10, false},
+		{"500k", 500 << 10, false},
+		{"4M", 4 << 20, false},
+		{"1.5M", 3 << 19, false},
+		{"2G", 2 << 30, false},
+		{"4MB", 4 << 20, false},
+		{"4MiB", 4 << 20, false},
+		{"4MiB/s", 4 << 20, false},
+		{"500K/s", 500 << 10, false},
+		{"1000B", 1000, false},
+		{" 4M ", 4 << 20, false},
+		{"", 0, true},
+		{"M", 0, true},
+		{"0", 0, true},
+		{"-4M", 0, true},
+		{"0.5", 0, true},
+		{"5i", 0, true},
+		{"5iB", 0, true},
+		{"5Ki", 0, true},
+		{"4T", 0, true},
+		{"fast", 0, true},
+		{"NaN", 0, true},
+		{"Inf", 0, true},
+		{"+InfM", 0, true},
+		{"1e300G", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseRate(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseRate(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseRate(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestApplyRateLimit(t *testing.T) {
+	defer func() { rateLimit = "" }()
+
+	tests := []struct {
+		rate    string
+		workers int
+		want    int
+		wantErr bool
+	}{
+		{"", 8, 8, false},
+		{"4M", 8, 4, false},
+		{"100M", 8, 8, false},
+		{"500K", 8, 1, false},
+		{"NaN", 8, 0, true},
+		{"1e300G", 8, 0, true},
+	}
+	for _, tt := range tests {
+		rateLimit = tt.rate
+		got, err := applyRateLimit(tt.workers)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("applyRateLimit(%d) with --rate-limit %q error = %v, wantErr %v", tt.workers, tt.rate, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("applyRateLimit(%d) with --rate-limit %q = %d, want %d", tt.workers, tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestApplyLayout(t *testing.T) {
+	tests := []struct {
+		layout  string
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{config.LayoutFlat, "https://github.com/jsmith/lab1", "lab1", false},
+		{config.LayoutOwner, "https://github.com/jsmith/lab1", "jsmith-lab1", false},
+		{config.LayoutNested, "https://github.com/jsmith/lab1", "jsmith/lab1", false},
+		{config.LayoutNested, "git@github.com:jsmith/lab1.git", "jsmith/lab1", false},
+		{config.LayoutNested, "https://example.com/lab1", "lab1", false},
+		{config.LayoutNested, "https://example.com/org/../lab1", "", true},
+		{config.LayoutOwner, "https://example.com/org/../lab1", "..-lab1", false},
+	}
+	for _, tt := range tests {
+		repos := []api.Repo{{Name: "lab1", URL: tt.url}}
+		err := applyLayout(repos, tt.layout)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("applyLayout(%q, %q) error = %v, wantErr %v", tt.url, tt.layout, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && repos[0].Name != tt.want {
+			t.Errorf("applyLayout(%q, %q) name = %q, want %q", tt.url, tt.layout, repos[0].Name, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/spf13/cobra"
+)
+
+// Build metadata, set with -ldflags "-X" by release builds (see .goreleaser.yaml).
+// Local builds fall back to the VCS information recorded by the Go toolchain.
+var (
+	commit    = ""
+	buildDate = ""
+)
+
+var versionFull bool
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionFull, "full", false, "Also print the commit, build date, Go version, and platform (useful in bug reports)")
+	rootCmd.AddCommand(versionCmd)
+}
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version of repoman",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Printf("repoman %s\n", version)
+		if !versionFull {
+			return
+		}
+		c, date := buildInfo()
+		fmt.Printf("commit:     %s\n", c)
+		fmt.Printf("built:      %s\n", date)
+		fmt.Printf("go version: %s\n", runtime.Version())
+		fmt.Printf("platform:   %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	},
+}
+
+// buildInfo returns the commit and build date set at link time, falling back to the
+// revision and commit time that the Go toolchain records for builds in a git checkout.
+// Unknown values are returned as "unknown".
+func buildInfo() (rev, date string) {
+	rev, date = commit, buildDate
+	if info, ok := debug.ReadBuildInfo(); ok && (rev == "" || date == "") {
+		modified := false
+		var vcsRev, vcsTime string
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				vcsRev = s.Value
+			case "vcs.time":
+				vcsTime = s.Value
+			case "vcs.modified":
+				modified = s.Value == "true"
+			}
+		}
+		if rev == "" && vcsRev != "" {
+			rev = vcsRev
+			if modified {
+				rev += " (modified)"
+			}
+		}
+		if date == "" && vcsTime != "" {
+			date = vcsTime + " (commit time)"
+		}
+	}
+	if rev == "" {
+		rev = "unknown"
+	}
+	if date == "" {
+		date = "unknown"
+	}
+	return rev, date
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/liffiton/repoman/internal/config"
+	"github.com/liffiton/repoman/internal/ui"
+	"github.com/liffiton/repoman/internal/webhook"
+)
+
+// webhookWaitTimeout bounds how long a command waits for webhook delivery before exiting.
+const webhookWaitTimeout = 15 * time.Second
+
+// newWebhookEvent creates a webhook event of the given type for the workspace.
+func newWebhookEvent(eventType string, wcfg *config.WorkspaceConfig) webhook.Event {
+	return webhook.Event{
+		Type:           eventType,
+		CourseID:       wcfg.CourseID,
+		CourseName:     wcfg.CourseName,
+		AssignmentID:   wcfg.AssignmentID,
+		AssignmentName: wcfg.AssignmentName,
+	}
+}
+
+// postWebhook sends the event in the background to the webhook URL configured for the
+// workspace, falling back to the user config. It returns a function that waits (bounded)
+// for delivery and reports a failure as a warning; the command's result is never affected.
+func postWebhook(wcfg *config.WorkspaceConfig, event webhook.Event) func() {
+	url := wcfg.WebhookURL
+	if url == "" {
+		url = cfg.WebhookURL
+	}
+	if url == "" {
+		return func() {}
+	}
+
+	poster := webhook.NewPoster(url)
+	poster.Post(event)
+	return func() {
+		if err := poster.Wait(webhookWaitTimeout); err != nil {
+			ui.Warning.Printf("Failed to send webhook: %v\n", err)
+		}
+	}
+}
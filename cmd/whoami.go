@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/liffiton/repoman/internal/api"
+	"github.com/liffiton/repoman/internal/config"
+	"github.com/liffiton/repoman/internal/ui"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(whoamiCmd)
+}
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Verify that the configured API key and base URL work",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ui.PrintHeader("Check Authentication")
+		pterm.Println()
+
+		if err := requireAuth(); err != nil {
+			return err
+		}
+
+		if cfg.Profile != config.DefaultProfile {
+			ui.Info.Printf("Profile: %s\n", cfg.Profile)
+		}
+		ui.Info.Printf("Base URL: %s\n", cfg.GetBaseURL())
+		switch cfg.GetAPIKeySource() {
+		case config.KeySourceEnv:
+			ui.Info.Printf("API Key: From the %s environment variable.\n", config.APIKeyEnvVar)
+		case config.KeySourceKeyring:
+			ui.Info.Println("API Key: Stored in the system keyring.")
+		case config.KeySourceFile:
+			configPath, _ := config.GetConfigPath()
+			ui.Info.Printf("API Key: Stored in the config file (%s).\n", configPath)
+		}
+		pterm.Println()
+
+		client, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+		// Always check against the server, never a cached response.
+		client.SetCache(nil)
+
+		courses, err := client.GetCoursesCtx(cmd.Context())
+		if err != nil {
+			if errors.Is(err, api.ErrUnauthorized) {
+				return errors.New("the server rejected the API key (invalid API key). Run 'repoman auth' to configure a new one")
+			}
+			return fmt.Errorf("failed to contact server: %w", err)
+		}
+
+		ui.Success.Print("Authenticated successfully. ")
+		fmt.Printf("%d courses available.\n", len(courses))
+		return nil
+	},
+}
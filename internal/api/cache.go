@@ -0,0 +1,61 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache stores successful GET responses on disk so that repeated runs can skip
+// re-fetching unchanged data. Entries are keyed by base URL, API key, and request
+// path, so switching servers or accounts never serves another's data.
+type Cache struct {
+	// Dir is the directory holding cache entries. It is created if needed.
+	Dir string
+	// TTL is how long an entry remains valid.
+	TTL time.Duration
+	// Refresh skips reading cached entries (but still stores fresh responses).
+	Refresh bool
+}
+
+// cacheKey returns the file name for a cached response.
+// The API key is hashed so that it never appears on disk in plain text.
+func cacheKey(baseURL, apiKey, path string) string {
+	keyHash := sha256.Sum256([]byte(apiKey))
+	sum := sha256.Sum256([]byte(baseURL + "\x00" + hex.EncodeToString(keyHash[:]) + "\x00" + path))
+	return hex.EncodeToString(sum[:]) + ".json"
+}
+
+// get returns the cached response body, if there is an unexpired entry.
+func (c *Cache) get(baseURL, apiKey, path string) ([]byte, bool) {
+	if c.Refresh || c.TTL <= 0 {
+		return nil, false
+	}
+
+	file := filepath.Join(c.Dir, cacheKey(baseURL, apiKey, path))
+	info, err := os.Stat(file)
+	if err != nil || time.Since(info.ModTime()) > c.TTL {
+		return nil, false
+	}
+
+	// #nosec G304
+	body, err := os.ReadFile(file)
+	if err != nil {
+		return nil, false
+	}
+	return body, true
+}
+
+// put stores a response body in the cache. Failures are ignored, as the cache is
+// only an optimization.
+func (c *Cache) put(baseURL, apiKey, path string, body []byte) {
+	if c.TTL <= 0 {
+		return
+	}
+	if err := os.MkdirAll(c.Dir, 0o700); err != nil {
+		return
+	}
+	_ = os.WriteFile(filepath.Join(c.Dir, cacheKey(baseURL, apiKey, path)), body, 0o600)
+}
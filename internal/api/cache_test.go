@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "repoman-api-cache-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]Repo{{Name: "alice", URL: "https://github.com/user/alice"}})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "test-key")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	cache := &Cache{Dir: tmpDir, TTL: time.Hour}
+	client.SetCache(cache)
+
+	for i := 0; i < 2; i++ {
+		repos, err := client.GetAssignmentRepos("lab1")
+		if err != nil {
+			t.Fatalf("GetAssignmentRepos failed: %v", err)
+		}
+		if len(repos) != 1 || repos[0].Name != "alice" {
+			t.Errorf("unexpected repos: %+v", repos)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected 1 request with cache, got %d", got)
+	}
+
+	// Refresh bypasses the cache
+	cache.Refresh = true
+	if _, err := client.GetAssignmentRepos("lab1"); err != nil {
+		t.Fatalf("GetAssignmentRepos failed: %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected refresh to hit the server, got %d requests", got)
+	}
+	cache.Refresh = false
+
+	// A different API key must not share entries
+	other, err := NewClient(server.URL, "other-key")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	other.SetCache(cache)
+	if _, err := other.GetAssignmentRepos("lab1"); err != nil {
+		t.Fatalf("GetAssignmentRepos failed: %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("expected a different API key to miss the cache, got %d requests", got)
+	}
+
+	// Expired entries are not used
+	cache.TTL = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if _, err := client.GetAssignmentRepos("lab1"); err != nil {
+		t.Fatalf("GetAssignmentRepos failed: %v", err)
+	}
+	if got := calls.Load(); got != 4 {
+		t.Errorf("expected expired entry to hit the server, got %d requests", got)
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	base := cacheKey("https://a.example.com", "key", "/courses")
+	if base == cacheKey("https://b.example.com", "key", "/courses") {
+		t.Error("expected different base URLs to have different keys")
+	}
+	if base == cacheKey("https://a.example.com", "other", "/courses") {
+		t.Error("expected different API keys to have different keys")
+	}
+	if base == cacheKey("https://a.example.com", "key", "/assignments/1/repos") {
+		t.Error("expected different paths to have different keys")
+	}
+}
@@ -2,16 +2,37 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
 
+const (
+	// defaultUserAgent identifies requests from clients that haven't set a version.
+	defaultUserAgent = "repoman/dev"
+
+	// defaultTimeout bounds each API request, so that a hung server can't wedge the CLI.
+	defaultTimeout = 10 * time.Second
+
+	// Retry settings for transient failures (5xx and 429 responses).
+	defaultMaxAttempts = 4
+	defaultBaseBackoff = 500 * time.Millisecond
+	defaultMaxBackoff  = 10 * time.Second
+)
+
+// ErrUnauthorized is returned when the server rejects the API key.
+var ErrUnauthorized = errors.New("unauthorized: invalid API key")
+
 // Course represents a course in the web application.
 type Course struct {
 	ID   string `json:"id"`
@@ -30,79 +51,303 @@ type Repo struct {
 	URL  string `json:"url"`
 }
 
+// Owner returns the owner (e.g., the student's username) from the repository's URL, or ""
+// if the URL has none.
+func (r Repo) Owner() string {
+	return extractRepoOwner(r.URL)
+}
+
 // Client is a client for the Repoman web application.
 type Client struct {
-	httpClient *http.Client
-	baseURL    *url.URL
-	apiKey     string
+	httpClient  *http.Client
+	baseURL     *url.URL
+	apiKey      string
+	userAgent   string
+	cache       *Cache
+	logger      *log.Logger
+	slogger     *slog.Logger
+	maxAttempts int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
 }
 
-// NewClient creates a new API client.
+// NewClient creates a new API client using an HTTP client with a default timeout.
 func NewClient(baseURLStr, apiKey string) (*Client, error) {
+	return NewClientWithHTTPClient(baseURLStr, apiKey, &http.Client{
+		Timeout: defaultTimeout,
+	})
+}
+
+// NewClientWithHTTPClient creates a new API client that sends requests with the given
+// HTTP client, e.g., to control the transport, proxy, TLS settings, or timeout.
+// If hc is nil, a client with the default timeout is used.
+func NewClientWithHTTPClient(baseURLStr, apiKey string, hc *http.Client) (*Client, error) {
 	u, err := url.Parse(baseURLStr)
 	if err != nil {
 		return nil, fmt.Errorf("invalid base URL: %w", err)
 	}
+	if hc == nil {
+		hc = &http.Client{Timeout: defaultTimeout}
+	}
 
 	return &Client{
-		baseURL: u,
-		apiKey:  apiKey,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		baseURL:     u,
+		apiKey:      apiKey,
+		httpClient:  hc,
+		userAgent:   defaultUserAgent,
+		maxAttempts: defaultMaxAttempts,
+		baseBackoff: defaultBaseBackoff,
+		maxBackoff:  defaultMaxBackoff,
 	}, nil
 }
 
-func (c *Client) doRequest(method, path string) (*http.Response, error) {
+// SetUserAgent sets the User-Agent header sent with each request (e.g., "repoman/1.2.3").
+func (c *Client) SetUserAgent(userAgent string) {
+	c.userAgent = userAgent
+}
+
+// SetCache enables caching of GET responses in the given cache. Pass nil to disable caching.
+func (c *Client) SetCache(cache *Cache) {
+	c.cache = cache
+}
+
+// SetLogger sets a logger that records the method, path, and status of each request.
+// Pass nil to disable logging.
+func (c *Client) SetLogger(logger *log.Logger) {
+	c.logger = logger
+}
+
+// SetStructuredLogger sets a logger that records the method, path, status, and duration
+// of each request, and each response served from the cache. Pass nil to disable logging.
+func (c *Client) SetStructuredLogger(logger *slog.Logger) {
+	c.slogger = logger
+}
+
+// SetProxy sends requests through the proxy at proxyURL, overriding the HTTP_PROXY,
+// HTTPS_PROXY, and NO_PROXY environment variables, which are otherwise honored. Pass nil
+// to use the environment again. It returns an error if the client was created with an
+// HTTP client whose transport is not an *http.Transport.
+func (c *Client) SetProxy(proxyURL *url.URL) error {
+	var transport *http.Transport
+	switch t := c.httpClient.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return fmt.Errorf("cannot set a proxy on transport of type %T", t)
+	}
+
+	transport.Proxy = http.ProxyFromEnvironment
+	if proxyURL != nil {
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	// Copy the client so that one passed to NewClientWithHTTPClient is not modified.
+	hc := *c.httpClient
+	hc.Transport = transport
+	c.httpClient = &hc
+	return nil
+}
+
+// logf logs a message if a logger is set.
+func (c *Client) logf(format string, args ...any) {
+	if c.logger != nil {
+		c.logger.Printf(format, args...)
+	}
+}
+
+// logRequest records a request to the structured logger, if one is set. Requests that
+// failed or received an error status are logged at the error level.
+func (c *Client) logRequest(ctx context.Context, method, path string, attrs ...slog.Attr) {
+	if c.slogger == nil {
+		return
+	}
+	level := slog.LevelInfo
+	for _, a := range attrs {
+		if a.Key == "error" || (a.Key == "status" && a.Value.Int64() >= 400) {
+			level = slog.LevelError
+		}
+	}
+	attrs = append([]slog.Attr{slog.String("method", method), slog.String("path", path)}, attrs...)
+	c.slogger.LogAttrs(ctx, level, "api request", attrs...)
+}
+
+// doRequestCtx performs an API request, returning the response if it succeeded.
+// Server errors (5xx) and 429 Too Many Requests are retried with backoff, up to
+// maxAttempts in total; other failures are returned immediately.
+// Uses the provided context for timeout/cancellation control, in addition to the
+// client's per-request timeout.
+func (c *Client) doRequestCtx(ctx context.Context, method, path string) (*http.Response, error) {
 	u, err := url.JoinPath(c.baseURL.String(), "api", "v1", path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to construct URL: %w", err)
 	}
 
-	req, err := http.NewRequest(method, u, http.NoBody)
+	req, err := http.NewRequestWithContext(ctx, method, u, http.NoBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	req.Header.Set("User-Agent", c.userAgent)
 	if c.apiKey != "" {
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
 	}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+	var resp *http.Response
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		resp, err = c.httpClient.Do(req)
+		if err != nil {
+			c.logf("%s %s: %v", method, path, err)
+			c.logRequest(ctx, method, path, slog.String("error", err.Error()), slog.Duration("duration", time.Since(start)))
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+		c.logf("%s %s: %d (%s)", method, path, resp.StatusCode, time.Since(start).Round(time.Millisecond))
+		c.logRequest(ctx, method, path, slog.Int("status", resp.StatusCode), slog.Duration("duration", time.Since(start)))
+
+		if !isRetryable(resp.StatusCode) || attempt >= c.maxAttempts {
+			break
+		}
+
+		delay := c.retryDelay(attempt, resp.Header.Get("Retry-After"))
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		_ = resp.Body.Close()
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("request failed: %w", ctx.Err())
+		case <-time.After(delay):
+		}
 	}
 
 	if resp.StatusCode == http.StatusUnauthorized {
 		_ = resp.Body.Close()
-		return nil, errors.New("unauthorized: invalid API key")
+		return nil, ErrUnauthorized
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		// Read a snippet of the error body for more context
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody+1))
 		_ = resp.Body.Close()
 
-		errMsg := strings.TrimSpace(string(body))
-		if errMsg != "" {
-			return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, errMsg)
+		return nil, &StatusError{
+			StatusCode: resp.StatusCode,
+			Message:    errorMessage(body),
 		}
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
 	return resp, nil
 }
 
+// maxErrorBody is the maximum number of bytes of an error response body included in errors.
+const maxErrorBody = 512
+
+// StatusError is returned when the server responds with an unexpected status code.
+// Message holds the server's explanation, if it provided one.
+type StatusError struct {
+	Message    string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("unexpected status code %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// errorMessage extracts a human-readable message from an error response body.
+// It prefers the "error" (or "message") field of a JSON body, falling back to the
+// raw body text, truncated to maxErrorBody bytes.
+func errorMessage(body []byte) string {
+	var parsed struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		if msg := strings.TrimSpace(parsed.Error); msg != "" {
+			return msg
+		}
+		if msg := strings.TrimSpace(parsed.Message); msg != "" {
+			return msg
+		}
+	}
+
+	truncated := len(body) > maxErrorBody
+	if truncated {
+		body = body[:maxErrorBody]
+	}
+	msg := strings.Join(strings.Fields(string(body)), " ")
+	if truncated && msg != "" {
+		msg += "..."
+	}
+	return msg
+}
+
+// isRetryable reports whether a response status code indicates a transient failure.
+func isRetryable(statusCode int) bool {
+	return statusCode >= 500 || statusCode == http.StatusTooManyRequests
+}
+
+// retryDelay returns how long to wait before the next attempt. It honors a Retry-After
+// header (in seconds or as an HTTP date) when present, and otherwise backs off
+// exponentially. The delay is capped at maxBackoff either way.
+func (c *Client) retryDelay(attempt int, retryAfter string) time.Duration {
+	delay := c.baseBackoff << (attempt - 1)
+	if retryAfter != "" {
+		if secs, err := strconv.Atoi(retryAfter); err == nil && secs >= 0 {
+			delay = time.Duration(secs) * time.Second
+		} else if t, err := http.ParseTime(retryAfter); err == nil {
+			delay = max(time.Until(t), 0)
+		}
+	}
+	return min(delay, c.maxBackoff)
+}
+
+// getCtx performs a GET request and returns the response body, using the response
+// cache if one is configured.
+func (c *Client) getCtx(ctx context.Context, path string) ([]byte, error) {
+	if c.cache != nil {
+		if body, ok := c.cache.get(c.baseURL.String(), c.apiKey, path); ok {
+			c.logf("GET %s: cached", path)
+			c.logRequest(ctx, "GET", path, slog.Bool("cached", true))
+			return body, nil
+		}
+	}
+
+	resp, err := c.doRequestCtx(ctx, "GET", path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if c.cache != nil {
+		c.cache.put(c.baseURL.String(), c.apiKey, path, body)
+	}
+	return body, nil
+}
+
 // GetCourses fetches the list of courses.
 func (c *Client) GetCourses() ([]Course, error) {
-	resp, err := c.doRequest("GET", "/courses")
+	return c.GetCoursesCtx(context.Background())
+}
+
+// GetCoursesCtx fetches the list of courses.
+// Uses the provided context for timeout/cancellation control.
+func (c *Client) GetCoursesCtx(ctx context.Context) ([]Course, error) {
+	body, err := c.getCtx(ctx, "/courses")
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
 
 	var courses []Course
-	if err := json.NewDecoder(resp.Body).Decode(&courses); err != nil {
+	if err := json.Unmarshal(body, &courses); err != nil {
 		return nil, fmt.Errorf("failed to decode courses: %w", err)
 	}
 	return courses, nil
@@ -110,15 +355,20 @@ func (c *Client) GetCourses() ([]Course, error) {
 
 // GetAssignments fetches the list of assignments for a course.
 func (c *Client) GetAssignments(courseID string) ([]Assignment, error) {
+	return c.GetAssignmentsCtx(context.Background(), courseID)
+}
+
+// GetAssignmentsCtx fetches the list of assignments for a course.
+// Uses the provided context for timeout/cancellation control.
+func (c *Client) GetAssignmentsCtx(ctx context.Context, courseID string) ([]Assignment, error) {
 	path := fmt.Sprintf("/courses/%s/assignments", courseID)
-	resp, err := c.doRequest("GET", path)
+	body, err := c.getCtx(ctx, path)
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
 
 	var assignments []Assignment
-	if err := json.NewDecoder(resp.Body).Decode(&assignments); err != nil {
+	if err := json.Unmarshal(body, &assignments); err != nil {
 		return nil, fmt.Errorf("failed to decode assignments: %w", err)
 	}
 	return assignments, nil
@@ -126,15 +376,20 @@ func (c *Client) GetAssignments(courseID string) ([]Assignment, error) {
 
 // GetAssignmentRepos fetches the list of repositories for an assignment.
 func (c *Client) GetAssignmentRepos(assignmentID string) ([]Repo, error) {
+	return c.GetAssignmentReposCtx(context.Background(), assignmentID)
+}
+
+// GetAssignmentReposCtx fetches the list of repositories for an assignment.
+// Uses the provided context for timeout/cancellation control.
+func (c *Client) GetAssignmentReposCtx(ctx context.Context, assignmentID string) ([]Repo, error) {
 	path := fmt.Sprintf("/assignments/%s/repos", assignmentID)
-	resp, err := c.doRequest("GET", path)
+	body, err := c.getCtx(ctx, path)
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
 
 	var repos []Repo
-	if err := json.NewDecoder(resp.Body).Decode(&repos); err != nil {
+	if err := json.Unmarshal(body, &repos); err != nil {
 		return nil, fmt.Errorf("failed to decode repos: %w", err)
 	}
 
@@ -148,10 +403,58 @@ func (c *Client) GetAssignmentRepos(assignmentID string) ([]Repo, error) {
 	return repos, nil
 }
 
+// DisambiguateNames renames repositories whose names collide, since each is cloned into a
+// directory named after it. A colliding name gets the owner from its URL appended (e.g.,
+// "lab1-jsmith"), plus a numeric suffix if that is still not unique. It returns the
+// original names that collided.
+func DisambiguateNames(repos []Repo) []string {
+	counts := make(map[string]int, len(repos))
+	for _, r := range repos {
+		counts[r.Name]++
+	}
+
+	var collided []string
+	taken := make(map[string]bool, len(repos))
+	for _, r := range repos {
+		if counts[r.Name] == 1 {
+			taken[r.Name] = true
+		}
+	}
+	for i, r := range repos {
+		if counts[r.Name] == 1 {
+			continue
+		}
+		if !slices.Contains(collided, r.Name) {
+			collided = append(collided, r.Name)
+		}
+
+		base := r.Name
+		if owner := extractRepoOwner(r.URL); owner != "" {
+			base += "-" + owner
+		}
+		name := base
+		for n := 2; taken[name]; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		taken[name] = true
+		repos[i].Name = name
+	}
+	return collided
+}
+
+// trimRepoURL removes any query string, fragment, ".git" suffix, and trailing slash from a git URL.
+func trimRepoURL(repoURL string) string {
+	if idx := strings.IndexAny(repoURL, "?#"); idx >= 0 {
+		repoURL = repoURL[:idx]
+	}
+	repoURL = strings.TrimSuffix(repoURL, ".git")
+	return strings.TrimSuffix(repoURL, "/")
+}
+
 // extractRepoName extracts the repository name from a git URL.
+// Any query string or fragment (e.g., from a URL copied from a browser) is ignored.
 func extractRepoName(repoURL string) string {
-	repoURL = strings.TrimSuffix(repoURL, ".git")
-	repoURL = strings.TrimSuffix(repoURL, "/")
+	repoURL = trimRepoURL(repoURL)
 	if idx := strings.LastIndex(repoURL, "/"); idx >= 0 {
 		repoURL = repoURL[idx+1:]
 	}
@@ -160,3 +463,23 @@ func extractRepoName(repoURL string) string {
 	}
 	return repoURL
 }
+
+// extractRepoOwner extracts the owner (the path component before the repository name) from
+// a git URL, or returns "" if there is none.
+func extractRepoOwner(repoURL string) string {
+	repoURL = trimRepoURL(repoURL)
+	idx := strings.LastIndexAny(repoURL, "/:")
+	if idx < 0 || repoURL[idx] == ':' {
+		// Either no path at all, or a scp-like URL with the repo directly after the host.
+		return ""
+	}
+	repoURL = repoURL[:idx]
+	if idx := strings.LastIndexAny(repoURL, "/:"); idx >= 0 {
+		if strings.HasSuffix(repoURL[:idx], "/") {
+			// The component is a host (e.g., "https://host/repo"), not an owner.
+			return ""
+		}
+		return repoURL[idx+1:]
+	}
+	return ""
+}
@@ -1,10 +1,20 @@
 package api
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"log"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestGetCourses(t *testing.T) {
@@ -101,6 +111,337 @@ func TestGetAssignmentRepos(t *testing.T) {
 	}
 }
 
+func TestGetCoursesCtxCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Hang until the client gives up
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "test-key")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = client.GetCoursesCtx(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request was not canceled promptly (took %v)", elapsed)
+	}
+}
+
+func TestRetryTransientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusServiceUnavailable, http.StatusTooManyRequests} {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) <= 2 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(status)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode([]Course{{ID: "cs101", Name: "Intro to CS"}})
+		}))
+
+		client, err := NewClient(server.URL, "test-key")
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		client.baseBackoff = time.Millisecond
+
+		courses, err := client.GetCourses()
+		if err != nil {
+			t.Errorf("status %d: expected success after retries, got %v", status, err)
+		} else if len(courses) != 1 {
+			t.Errorf("status %d: unexpected courses: %+v", status, courses)
+		}
+		if got := calls.Load(); got != 3 {
+			t.Errorf("status %d: expected 3 attempts, got %d", status, got)
+		}
+		server.Close()
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "test-key")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	client.baseBackoff = time.Millisecond
+
+	if _, err := client.GetCourses(); err == nil {
+		t.Error("expected an error after exhausting retries")
+	}
+	if got := calls.Load(); got != defaultMaxAttempts {
+		t.Errorf("expected %d attempts, got %d", defaultMaxAttempts, got)
+	}
+}
+
+func TestNoRetryOnClientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound} {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(status)
+		}))
+
+		client, err := NewClient(server.URL, "test-key")
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+
+		_, err = client.GetCourses()
+		if err == nil {
+			t.Errorf("status %d: expected an error", status)
+		}
+		if (status == http.StatusUnauthorized) != errors.Is(err, ErrUnauthorized) {
+			t.Errorf("status %d: unexpected ErrUnauthorized match for %v", status, err)
+		}
+		if got := calls.Load(); got != 1 {
+			t.Errorf("status %d: expected 1 attempt, got %d", status, got)
+		}
+		server.Close()
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	client := &Client{baseBackoff: time.Second, maxBackoff: 5 * time.Second}
+
+	tests := []struct {
+		retryAfter string
+		attempt    int
+		want       time.Duration
+	}{
+		{"", 1, time.Second},
+		{"", 2, 2 * time.Second},
+		{"", 5, 5 * time.Second}, // capped
+		{"3", 1, 3 * time.Second},
+		{"120", 1, 5 * time.Second}, // capped
+		{"bogus", 2, 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := client.retryDelay(tt.attempt, tt.retryAfter); got != tt.want {
+			t.Errorf("retryDelay(%d, %q) = %v, want %v", tt.attempt, tt.retryAfter, got, tt.want)
+		}
+	}
+}
+
+func TestServerErrorMessage(t *testing.T) {
+	tests := []struct {
+		body        string
+		contentType string
+		want        string
+	}{
+		{`{"error": "assignment lab9 does not exist"}`, "application/json", "unexpected status code 400: assignment lab9 does not exist"},
+		{`{"message": "course is archived"}`, "application/json", "unexpected status code 400: course is archived"},
+		{"Bad request:\n  missing id", "text/plain", "unexpected status code 400: Bad request: missing id"},
+		{"", "text/plain", "unexpected status code: 400"},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", tt.contentType)
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(tt.body))
+		}))
+
+		client, err := NewClient(server.URL, "test-key")
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+
+		_, err = client.GetAssignmentRepos("lab9")
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) {
+			t.Errorf("expected *StatusError, got %v", err)
+		} else if statusErr.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status code 400, got %d", statusErr.StatusCode)
+		}
+		if err != nil && err.Error() != tt.want {
+			t.Errorf("error = %q, want %q", err.Error(), tt.want)
+		}
+		server.Close()
+	}
+}
+
+func TestErrorMessageTruncated(t *testing.T) {
+	body := []byte(strings.Repeat("x", maxErrorBody+1))
+	msg := errorMessage(body)
+	if len(msg) != maxErrorBody+len("...") || !strings.HasSuffix(msg, "...") {
+		t.Errorf("expected truncated message, got %d bytes: %q", len(msg), msg)
+	}
+}
+
+type recordingTransport struct {
+	requests []*http.Request
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.requests = append(rt.requests, req)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestNewClientWithHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]Course{})
+	}))
+	defer server.Close()
+
+	transport := &recordingTransport{}
+	client, err := NewClientWithHTTPClient(server.URL, "test-key", &http.Client{Transport: transport})
+	if err != nil {
+		t.Fatalf("NewClientWithHTTPClient failed: %v", err)
+	}
+	if _, err := client.GetCourses(); err != nil {
+		t.Fatalf("GetCourses failed: %v", err)
+	}
+
+	if len(transport.requests) != 1 {
+		t.Fatalf("expected 1 request through custom transport, got %d", len(transport.requests))
+	}
+	if auth := transport.requests[0].Header.Get("Authorization"); auth != "Bearer test-key" {
+		t.Errorf("expected Authorization header, got %q", auth)
+	}
+}
+
+func TestSetProxy(t *testing.T) {
+	// The proxy receives requests for the (unresolvable) API host in absolute form.
+	var got string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.String()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]Course{})
+	}))
+	defer proxy.Close()
+
+	client, err := NewClient("http://repoman.invalid", "test-key")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatalf("failed to parse proxy URL: %v", err)
+	}
+	if err := client.SetProxy(proxyURL); err != nil {
+		t.Fatalf("SetProxy failed: %v", err)
+	}
+
+	if _, err := client.GetCourses(); err != nil {
+		t.Fatalf("GetCourses through proxy failed: %v", err)
+	}
+	if want := "http://repoman.invalid/api/v1/courses"; got != want {
+		t.Errorf("expected proxied request for %q, got %q", want, got)
+	}
+
+	// A client with a custom transport can't be given a proxy.
+	custom, err := NewClientWithHTTPClient("http://repoman.invalid", "test-key", &http.Client{Transport: &recordingTransport{}})
+	if err != nil {
+		t.Fatalf("NewClientWithHTTPClient failed: %v", err)
+	}
+	if err := custom.SetProxy(proxyURL); err == nil {
+		t.Error("expected error setting a proxy on a custom transport")
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("User-Agent")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]Course{})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "test-key")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	if _, err := client.GetCourses(); err != nil {
+		t.Fatalf("GetCourses failed: %v", err)
+	}
+	if got != defaultUserAgent {
+		t.Errorf("expected default User-Agent %q, got %q", defaultUserAgent, got)
+	}
+
+	client.SetUserAgent("repoman/1.2.3")
+	if _, err := client.GetCourses(); err != nil {
+		t.Fatalf("GetCourses failed: %v", err)
+	}
+	if got != "repoman/1.2.3" {
+		t.Errorf("expected User-Agent repoman/1.2.3, got %q", got)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]Course{})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "test-key")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	var buf bytes.Buffer
+	client.SetLogger(log.New(&buf, "", 0))
+
+	if _, err := client.GetCourses(); err != nil {
+		t.Fatalf("GetCourses failed: %v", err)
+	}
+	if got := buf.String(); !strings.HasPrefix(got, "GET /courses: 200") {
+		t.Errorf("expected request to be logged, got %q", got)
+	}
+	if strings.Contains(buf.String(), "test-key") {
+		t.Errorf("log must not contain the API key: %q", buf.String())
+	}
+}
+
+func TestStructuredLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]Course{})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "test-key")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	var buf bytes.Buffer
+	client.SetStructuredLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	if _, err := client.GetCourses(); err != nil {
+		t.Fatalf("GetCourses failed: %v", err)
+	}
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("invalid JSON log %q: %v", buf.String(), err)
+	}
+	if record["method"] != "GET" || record["path"] != "/courses" || record["status"] != float64(200) || record["level"] != "INFO" {
+		t.Errorf("expected request to be logged, got %v", record)
+	}
+	if strings.Contains(buf.String(), "test-key") {
+		t.Errorf("log must not contain the API key: %q", buf.String())
+	}
+}
+
 func TestExtractRepoName(t *testing.T) {
 	tests := []struct {
 		url  string
@@ -112,6 +453,10 @@ func TestExtractRepoName(t *testing.T) {
 		{"git@github.com:repo.git", "repo"},
 		{"ssh://git@github.com/user/repo.git", "repo"},
 		{"https://github.com/user/repo/", "repo"},
+		{"https://host/u/repo?foo=bar", "repo"},
+		{"https://host/u/repo#readme", "repo"},
+		{"https://host/u/repo.git?foo=bar#readme", "repo"},
+		{"https://host/u/repo/?tab=readme", "repo"},
 	}
 
 	for _, tt := range tests {
@@ -120,3 +465,48 @@ func TestExtractRepoName(t *testing.T) {
 		}
 	}
 }
+
+func TestExtractRepoOwner(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/user/repo", "user"},
+		{"https://github.com/user/repo.git", "user"},
+		{"git@github.com:user/repo.git", "user"},
+		{"git@github.com:repo.git", ""},
+		{"ssh://git@github.com/user/repo.git", "user"},
+		{"https://host/repo", ""},
+		{"https://host/org/user/repo/?tab=readme", "user"},
+	}
+
+	for _, tt := range tests {
+		if got := extractRepoOwner(tt.url); got != tt.want {
+			t.Errorf("extractRepoOwner(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestDisambiguateNames(t *testing.T) {
+	repos := []Repo{
+		{Name: "lab1", URL: "https://github.com/alice/lab1.git"},
+		{Name: "lab1", URL: "git@github.com:bob/lab1.git"},
+		{Name: "lab2", URL: "https://github.com/carol/lab2.git"},
+		{Name: "lab3", URL: "https://github.com/dave/lab3.git"},
+		{Name: "lab3", URL: "https://gitlab.com/dave/lab3.git"},
+	}
+
+	collided := DisambiguateNames(repos)
+
+	if want := []string{"lab1", "lab3"}; !slices.Equal(collided, want) {
+		t.Errorf("collided = %v, want %v", collided, want)
+	}
+	var names []string
+	for _, r := range repos {
+		names = append(names, r.Name)
+	}
+	want := []string{"lab1-alice", "lab1-bob", "lab2", "lab3-dave", "lab3-dave-2"}
+	if !slices.Equal(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadRepoList reads a list of repositories from a file instead of the server; see
+// ParseRepoList for the formats accepted.
+func LoadRepoList(path string) ([]Repo, error) {
+	// #nosec G304
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repository list: %w", err)
+	}
+	repos, err := ParseRepoList(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository list %s: %w", path, err)
+	}
+	return repos, nil
+}
+
+// ParseRepoList parses a list of repositories, either as JSON in the same form the server
+// returns (an array of objects with "name" and "url") or as text with one repository per
+// line, given as "name url" or just "url". Blank lines and lines starting with "#" are
+// ignored. A missing name is taken from the URL, as for the server's lists.
+func ParseRepoList(data []byte) ([]Repo, error) {
+	var repos []Repo
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &repos); err != nil {
+			return nil, fmt.Errorf("failed to decode repos: %w", err)
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+			switch fields := strings.Fields(text); len(fields) {
+			case 1:
+				repos = append(repos, Repo{URL: fields[0]})
+			case 2:
+				repos = append(repos, Repo{Name: fields[0], URL: fields[1]})
+			default:
+				return nil, fmt.Errorf("line %d: expected \"name url\" or \"url\", got %q", line, text)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range repos {
+		if repos[i].URL == "" {
+			return nil, fmt.Errorf("repository %d has no URL", i+1)
+		}
+		if repos[i].Name == "" || repos[i].Name == "unknown" {
+			repos[i].Name = extractRepoName(repos[i].URL)
+		}
+		// Each repository is cloned into a directory named after it.
+		if !filepath.IsLocal(repos[i].Name) {
+			return nil, fmt.Errorf("invalid repository name %q: must be a relative path within the workspace", repos[i].Name)
+		}
+	}
+	return repos, nil
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseRepoList(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []Repo
+		wantErr bool
+	}{
+		{
+			name: "json",
+			data: `[{"name": "alice", "url": "git@github.com:org/alice.git"}, {"url": "https://github.com/org/bob"}]`,
+			want: []Repo{{Name: "alice", URL: "git@github.com:org/alice.git"}, {Name: "bob", URL: "https://github.com/org/bob"}},
+		},
+		{
+			name: "text",
+			data: "# lab 1\nalice git@github.com:org/alice.git\n\n  https://github.com/org/bob.git  \n",
+			want: []Repo{{Name: "alice", URL: "git@github.com:org/alice.git"}, {Name: "bob", URL: "https://github.com/org/bob.git"}},
+		},
+		{name: "empty", data: "\n# nothing yet\n", want: nil},
+		{name: "too many fields", data: "alice git@github.com:org/alice.git extra\n", wantErr: true},
+		{name: "missing url", data: `[{"name": "alice"}]`, wantErr: true},
+		{name: "bad json", data: `[{"name": "alice",`, wantErr: true},
+		{name: "name outside workspace", data: "../alice git@github.com:org/alice.git\n", wantErr: true},
+		{name: "absolute name", data: "/tmp/alice git@github.com:org/alice.git\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRepoList([]byte(tt.data))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRepoList failed: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoadRepoList(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "repoman-repolist-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	path := filepath.Join(tmpDir, "repos.txt")
+	if err := os.WriteFile(path, []byte("alice git@github.com:org/alice.git\n"), 0o600); err != nil {
+		t.Fatalf("failed to write repo list: %v", err)
+	}
+	repos, err := LoadRepoList(path)
+	if err != nil {
+		t.Fatalf("LoadRepoList failed: %v", err)
+	}
+	if len(repos) != 1 || repos[0].Name != "alice" {
+		t.Errorf("expected alice, got %v", repos)
+	}
+
+	if _, err := LoadRepoList(filepath.Join(tmpDir, "missing.txt")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
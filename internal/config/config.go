@@ -5,8 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/zalando/go-keyring"
 )
@@ -14,33 +18,102 @@ import (
 const (
 	serviceName       = "repoman"
 	keyName           = "api_key"
+	gitHubTokenName   = "github_token"
+	gitTokenName      = "git_token"
 	configFileName    = "config.json"
 	workspaceFileName = ".repoman.json"
 	defaultBaseURL    = "https://crm.unsatisfiable.net"
+	cacheDirName      = "cache"
+	updateCheckFile   = "last_update_check"
 )
 
+const (
+	// DefaultProfile is the name of the profile used when none is selected.
+	DefaultProfile = "default"
+	// ProfileEnvVar is the environment variable that selects the active profile.
+	ProfileEnvVar = "REPOMAN_PROFILE"
+	// APIKeyEnvVar is the environment variable that overrides the stored API key.
+	APIKeyEnvVar = "REPOMAN_API_KEY"
+	// BaseURLEnvVar is the environment variable that overrides the stored base URL.
+	BaseURLEnvVar = "REPOMAN_BASE_URL"
+	// GitHubTokenEnvVar is the environment variable that overrides the stored GitHub token.
+	GitHubTokenEnvVar = "GITHUB_TOKEN"
+	// GitTokenEnvVar is the environment variable that overrides the stored git HTTPS token.
+	GitTokenEnvVar = "REPOMAN_GIT_TOKEN"
+	// WorkspaceBoundaryEnvVar is the environment variable that sets the directory at which
+	// the search for a workspace stops (default: the user's home directory).
+	WorkspaceBoundaryEnvVar = "REPOMAN_WORKSPACE_BOUNDARY"
+)
+
+// Directory layouts for the clones in a workspace.
+const (
+	// LayoutFlat clones each repository into a directory named after it (the default).
+	LayoutFlat = "flat"
+	// LayoutOwner clones each repository into "<owner>-<repo>".
+	LayoutOwner = "owner"
+	// LayoutNested clones each repository into "<owner>/<repo>".
+	LayoutNested = "nested"
+)
+
+// ValidateLayout returns an error if layout is not a known directory layout.
+// An empty layout is valid and means LayoutFlat.
+func ValidateLayout(layout string) error {
+	switch layout {
+	case "", LayoutFlat, LayoutOwner, LayoutNested:
+		return nil
+	}
+	return fmt.Errorf("invalid layout %q (must be %s, %s, or %s)", layout, LayoutFlat, LayoutOwner, LayoutNested)
+}
+
+// WorkspaceVersion is the current version of the workspace config file format.
+// Older files are migrated when loaded; newer ones are rejected.
+const WorkspaceVersion = 2
+
 // WorkspaceConfig holds directory-specific configuration.
 type WorkspaceConfig struct {
+	Version        int    `json:"version"`
 	CourseID       string `json:"course_id"`
 	CourseName     string `json:"course_name"`
 	AssignmentID   string `json:"assignment_id"`
 	AssignmentName string `json:"assignment_name"`
-	Root           string `json:"-"`
+	WebhookURL     string `json:"webhook_url,omitempty"`
+	SSHKey         string `json:"ssh_key,omitempty"`
+	Root           string `json:"root,omitempty"`
+	// Layout is the directory layout for clones (see LayoutFlat); empty means LayoutFlat.
+	Layout string `json:"layout,omitempty"`
+	// UseHTTP makes sync clone and pull over HTTPS instead of SSH by default.
+	UseHTTP bool `json:"use_http,omitempty"`
+	// PostSyncHook is a command run by sync in each repository that synced successfully,
+	// with "{name}" and "{path}" replaced by the repository's name and absolute path,
+	// quoted for the shell.
+	PostSyncHook string `json:"post_sync_hook,omitempty"`
+	// SyncSelection is the list of repository names last chosen with sync --select.
+	SyncSelection []string `json:"sync_selection,omitempty"`
+	// StarterCommits is the number of commits in the assignment's starter code, left out
+	// of the commit counts shown by status --show-commits.
+	StarterCommits int `json:"starter_commits,omitempty"`
 }
 
 // FindWorkspaceRoot searches for the workspace configuration file starting from the
-// current directory and moving up the directory tree.
+// current directory and moving up the directory tree. The search stops after checking
+// the boundary directory (see searchBoundary) or the filesystem root, returning
+// os.ErrNotExist if no workspace was found.
 func FindWorkspaceRoot() (string, error) {
 	curr, err := os.Getwd()
 	if err != nil {
 		return "", err
 	}
+	boundary := searchBoundary()
 
 	for {
 		if _, err := os.Stat(filepath.Join(curr, workspaceFileName)); err == nil {
 			return curr, nil
 		}
 
+		if boundary != "" && sameDir(curr, boundary) {
+			break
+		}
+
 		parent := filepath.Dir(curr)
 		if parent == curr {
 			break
@@ -51,6 +124,53 @@ func FindWorkspaceRoot() (string, error) {
 	return "", os.ErrNotExist
 }
 
+// searchBoundary returns the directory at which FindWorkspaceRoot stops searching:
+// the REPOMAN_WORKSPACE_BOUNDARY environment variable if set, otherwise the user's
+// home directory. It returns "" if neither is available.
+func searchBoundary() string {
+	if boundary := os.Getenv(WorkspaceBoundaryEnvVar); boundary != "" {
+		return boundary
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return home
+}
+
+// FindEnclosingClone checks whether the current directory is inside a git repository
+// located below the given workspace root, such as one of the cloned student repositories.
+// It returns the top-level directory of that repository, or "" if there is none.
+func FindEnclosingClone(root string) (string, error) {
+	curr, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	// Compared as files rather than paths, which may differ by symlinks (e.g., a
+	// workspace reached through a symlinked home directory).
+	rootInfo, err := os.Stat(root)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if info, err := os.Stat(curr); err == nil && os.SameFile(info, rootInfo) {
+			break
+		}
+		if _, err := os.Stat(filepath.Join(curr, ".git")); err == nil {
+			return curr, nil
+		}
+
+		parent := filepath.Dir(curr)
+		if parent == curr {
+			break
+		}
+		curr = parent
+	}
+
+	return "", nil
+}
+
 // LoadWorkspace loads the workspace configuration. It searches for the config file
 // starting from the current directory and moving up.
 func LoadWorkspace() (*WorkspaceConfig, error) {
@@ -68,26 +188,167 @@ func LoadWorkspace() (*WorkspaceConfig, error) {
 	if err := json.Unmarshal(data, &wcfg); err != nil {
 		return nil, fmt.Errorf("could not unmarshal workspace config: %w", err)
 	}
-	wcfg.Root = root
+
+	if wcfg.Version > WorkspaceVersion {
+		return nil, fmt.Errorf("workspace config %s has version %d, but this version of repoman only supports up to version %d; please upgrade repoman (run 'repoman update')",
+			filepath.Join(root, workspaceFileName), wcfg.Version, WorkspaceVersion)
+	}
+
+	needsWrite := false
+	if wcfg.Version < WorkspaceVersion {
+		wcfg.migrate(root)
+		needsWrite = true
+	}
+
+	// If the workspace was moved or renamed, the recorded root is stale (or points
+	// at a different directory); use the root that was found instead.
+	if !sameDir(wcfg.Root, root) {
+		wcfg.Root = root
+		needsWrite = true
+	}
+
+	if needsWrite {
+		// Rewriting is best-effort: the updated config is usable even if the
+		// file can't be written (e.g., a read-only workspace).
+		_ = wcfg.write(filepath.Join(root, workspaceFileName))
+	}
+
 	return &wcfg, nil
 }
 
-// SaveWorkspace saves the workspace configuration to the current directory.
+// migrate upgrades a workspace config loaded from an older file format to the current
+// version. root is the directory containing the workspace config file.
+func (wcfg *WorkspaceConfig) migrate(root string) {
+	// Version 0 (unversioned) files have the same fields as version 1.
+	// Version 2 added the recorded workspace root.
+	if wcfg.Version < 2 {
+		wcfg.Root = root
+	}
+	wcfg.Version = WorkspaceVersion
+}
+
+// GetSSHKey returns the SSH identity file to use in this workspace: the workspace's
+// ssh_key if set, otherwise the one from the user config, or "" if neither is set.
+// A leading "~/" is expanded to the home directory, and relative paths are relative to
+// the workspace root. It returns an error if the file does not exist.
+func (wcfg *WorkspaceConfig) GetSSHKey(cfg *Config) (string, error) {
+	key := wcfg.SSHKey
+	if key == "" {
+		key = cfg.SSHKey
+	}
+	if key == "" {
+		return "", nil
+	}
+
+	path := key
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("could not expand ssh_key %q: %w", key, err)
+		}
+		path = filepath.Join(home, rest)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(wcfg.Root, path)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("invalid ssh_key %q: %w", key, err)
+	}
+	return path, nil
+}
+
+// sameDir reports whether two paths refer to the same existing directory.
+func sameDir(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(aInfo, bInfo)
+}
+
+// SaveWorkspace saves the workspace configuration to the current directory, recording
+// the directory's absolute path as the workspace root.
 func (wcfg *WorkspaceConfig) SaveWorkspace() error {
+	root, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("could not get current directory: %w", err)
+	}
+	wcfg.Version = WorkspaceVersion
+	wcfg.Root = root
+	return wcfg.write(workspaceFileName)
+}
+
+// write writes the workspace configuration to the given file.
+func (wcfg *WorkspaceConfig) write(path string) error {
 	data, err := json.MarshalIndent(wcfg, "", "  ")
 	if err != nil {
 		return fmt.Errorf("could not marshal workspace config: %w", err)
 	}
-	if err := os.WriteFile(workspaceFileName, data, 0o600); err != nil {
+	if err := os.WriteFile(path, data, 0o600); err != nil {
 		return fmt.Errorf("could not write workspace config: %w", err)
 	}
 	return nil
 }
 
 // Config holds the configuration for repoman.
+// APIKey and BaseURL hold the stored settings; use GetAPIKey and GetBaseURL to read the
+// effective values, which take environment variable overrides into account. Like the
+// API key, GitHubToken and GitToken are kept in the keyring when one is available.
 type Config struct {
-	APIKey  string `json:"api_key,omitempty"`
-	BaseURL string `json:"base_url,omitempty"`
+	APIKey      string `json:"api_key,omitempty"`
+	BaseURL     string `json:"base_url,omitempty"`
+	Concurrency int    `json:"concurrency,omitempty"`
+	// FetchConcurrency limits how many of status's concurrent checks fetch at once.
+	FetchConcurrency int    `json:"fetch_concurrency,omitempty"`
+	WebhookURL       string `json:"webhook_url,omitempty"`
+	CacheTTL         string `json:"cache_ttl,omitempty"`
+	UpdateChannel    string `json:"update_channel,omitempty"`
+	UpdateCheck      string `json:"update_check,omitempty"`
+	GitHubToken      string `json:"github_token,omitempty"`
+	GitToken         string `json:"git_token,omitempty"`
+	Proxy            string `json:"proxy,omitempty"`
+	SSHKey           string `json:"ssh_key,omitempty"`
+	StaleDays        int    `json:"stale_days,omitempty"`
+	Profile          string `json:"-"`
+
+	// Values from the environment, never saved.
+	envAPIKey      string
+	envBaseURL     string
+	envGitHubToken string
+	envGitToken    string
+
+	// Where the stored API key was loaded from (KeySourceKeyring or KeySourceFile).
+	storedKeySource KeySource
+}
+
+// KeySource describes where the API key in use was loaded from.
+type KeySource string
+
+const (
+	// KeySourceNone indicates no API key is configured.
+	KeySourceNone KeySource = ""
+	// KeySourceEnv indicates the API key comes from the REPOMAN_API_KEY environment variable.
+	KeySourceEnv KeySource = "environment"
+	// KeySourceKeyring indicates the API key comes from the system keyring.
+	KeySourceKeyring KeySource = "keyring"
+	// KeySourceFile indicates the API key comes from the config file.
+	KeySourceFile KeySource = "config file"
+)
+
+// fileConfig is the on-disk layout of the user config file. Settings are stored per
+// profile; the top-level fields are from the older single-profile format and are read
+// as the default profile.
+type fileConfig struct {
+	Config
+	Profiles map[string]Config `json:"profiles,omitempty"`
 }
 
 // SaveResult describes where the configuration was saved.
@@ -97,14 +358,141 @@ type SaveResult struct {
 	FileWritten bool
 }
 
-// GetBaseURL returns the configured base URL or the default one.
+// GetAPIKey returns the API key from the environment if set, otherwise the stored one.
+func (cfg *Config) GetAPIKey() string {
+	if cfg.envAPIKey != "" {
+		return cfg.envAPIKey
+	}
+	return cfg.APIKey
+}
+
+// GetAPIKeySource returns where the API key returned by GetAPIKey was loaded from.
+func (cfg *Config) GetAPIKeySource() KeySource {
+	if cfg.envAPIKey != "" {
+		return KeySourceEnv
+	}
+	if cfg.APIKey == "" {
+		return KeySourceNone
+	}
+	return cfg.storedKeySource
+}
+
+// GetBaseURL returns the base URL from the environment if set, otherwise the stored one
+// or the default.
 func (cfg *Config) GetBaseURL() string {
+	if cfg.envBaseURL != "" {
+		return cfg.envBaseURL
+	}
 	if cfg.BaseURL != "" {
 		return cfg.BaseURL
 	}
 	return defaultBaseURL
 }
 
+// ValidateBaseURL returns an error if baseURL is not an absolute http or https URL, such as
+// a host name entered without a scheme.
+func ValidateBaseURL(baseURL string) error {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid base URL %q: must be a URL such as %q", baseURL, defaultBaseURL)
+	}
+	return nil
+}
+
+// GetGitHubToken returns the GitHub token from the environment if set, otherwise the
+// stored one. It may be empty, in which case update checks are unauthenticated.
+func (cfg *Config) GetGitHubToken() string {
+	if cfg.envGitHubToken != "" {
+		return cfg.envGitHubToken
+	}
+	return cfg.GitHubToken
+}
+
+// GetGitToken returns the access token for git over HTTPS from the environment if set,
+// otherwise the stored one. It may be empty, in which case git's own credential helpers
+// are used.
+func (cfg *Config) GetGitToken() string {
+	if cfg.envGitToken != "" {
+		return cfg.envGitToken
+	}
+	return cfg.GitToken
+}
+
+// GetCacheTTL returns how long API responses are cached, parsed from the configured
+// duration (e.g., "10m"). Zero, the default, disables the cache.
+func (cfg *Config) GetCacheTTL() (time.Duration, error) {
+	if cfg.CacheTTL == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(cfg.CacheTTL)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("invalid cache_ttl %q: must be a duration such as \"5m\" or \"0s\"", cfg.CacheTTL)
+	}
+	return ttl, nil
+}
+
+// GetProxy returns the configured proxy for git and API requests, or nil if none is set,
+// in which case the HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment variables apply.
+func (cfg *Config) GetProxy() (*url.URL, error) {
+	if cfg.Proxy == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(cfg.Proxy)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") {
+		return nil, fmt.Errorf("invalid proxy %q: must be a URL such as \"http://proxy.example.com:8080\"", cfg.Proxy)
+	}
+	return u, nil
+}
+
+// GetUpdateCheckInterval returns how often commands check for a newer release, parsed
+// from the configured duration (e.g., "24h"). Zero, the default, disables the check.
+func (cfg *Config) GetUpdateCheckInterval() (time.Duration, error) {
+	if cfg.UpdateCheck == "" {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(cfg.UpdateCheck)
+	if err != nil || interval < 0 {
+		return 0, fmt.Errorf("invalid update_check %q: must be a duration such as \"24h\"", cfg.UpdateCheck)
+	}
+	return interval, nil
+}
+
+// LastUpdateCheck returns when the last automatic update check completed, or the zero
+// time if none has been recorded.
+func LastUpdateCheck() time.Time {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return time.Time{}
+	}
+	data, err := os.ReadFile(filepath.Join(configDir, "repoman", updateCheckFile))
+	if err != nil {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// RecordUpdateCheck records t as the time of the last automatic update check.
+func RecordUpdateCheck(t time.Time) error {
+	repomanDir, err := EnsureConfigDir()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(repomanDir, updateCheckFile), []byte(t.UTC().Format(time.RFC3339)+"\n"), 0o600)
+}
+
+// GetCacheDir returns the path to the directory for cached API responses without creating it.
+func GetCacheDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get user config dir: %w", err)
+	}
+	return filepath.Join(configDir, "repoman", cacheDirName), nil
+}
+
 // GetConfigPath returns the path to the repoman config file without creating directories.
 func GetConfigPath() (string, error) {
 	configDir, err := os.UserConfigDir()
@@ -127,56 +515,162 @@ func EnsureConfigDir() (string, error) {
 	return repomanDir, nil
 }
 
-// Load loads the configuration. It tries the keyring first for the API key,
-// then falls back to the config file.
-func Load() (*Config, error) {
-	cfg := &Config{}
-
-	// 1. Try to get API key from keyring
-	apiKey, err := keyring.Get(serviceName, keyName)
-	if err == nil {
-		cfg.APIKey = apiKey
+// ResolveProfile returns the name of the active profile: the given name if non-empty,
+// then the REPOMAN_PROFILE environment variable, then the default profile.
+func ResolveProfile(name string) string {
+	if name != "" {
+		return name
+	}
+	if env := os.Getenv(ProfileEnvVar); env != "" {
+		return env
 	}
+	return DefaultProfile
+}
 
-	// 2. Load from config file
-	configPath, err := GetConfigPath()
-	if err != nil {
-		return nil, err
+// keyringEntry returns the keyring entry name for the secret name (e.g., the API key) in
+// a profile. The default profile uses the original unsuffixed name.
+func keyringEntry(name, profile string) string {
+	if profile == DefaultProfile {
+		return name
 	}
+	return name + ":" + profile
+}
+
+// readFileConfig reads the config file. A missing file yields an empty configuration.
+// Settings in the older single-profile format are moved into the default profile.
+func readFileConfig(configPath string) (*fileConfig, bool, error) {
+	fc := &fileConfig{Profiles: map[string]Config{}}
 
 	// #nosec G304
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return cfg, nil
+			return fc, false, nil
 		}
-		return nil, fmt.Errorf("could not read config file: %w", err)
+		return nil, false, fmt.Errorf("could not read config file: %w", err)
 	}
 
-	var fileCfg Config
-	if err := json.Unmarshal(data, &fileCfg); err != nil {
-		return nil, fmt.Errorf("could not unmarshal config: %w", err)
+	if err := json.Unmarshal(data, fc); err != nil {
+		return nil, true, fmt.Errorf("could not unmarshal config: %w", err)
 	}
+	if fc.Profiles == nil {
+		fc.Profiles = map[string]Config{}
+	}
+	if _, ok := fc.Profiles[DefaultProfile]; !ok && fc.Config != (Config{}) {
+		fc.Profiles[DefaultProfile] = fc.Config
+	}
+	fc.Config = Config{}
 
-	// If APIKey wasn't in keyring, use the one from the file
-	if cfg.APIKey == "" {
+	return fc, true, nil
+}
+
+// Load loads the configuration for the given profile, resolved with ResolveProfile.
+// Settings are taken in order of precedence from environment variables
+// (REPOMAN_API_KEY, REPOMAN_BASE_URL, GITHUB_TOKEN, REPOMAN_GIT_TOKEN), the keyring (API key and tokens), the config file,
+// and finally the defaults. Environment values are available through the getters
+// but are never written back by Save.
+func Load(profile string) (*Config, error) {
+	cfg := &Config{
+		Profile:        ResolveProfile(profile),
+		envAPIKey:      os.Getenv(APIKeyEnvVar),
+		envBaseURL:     os.Getenv(BaseURLEnvVar),
+		envGitHubToken: os.Getenv(GitHubTokenEnvVar),
+		envGitToken:    os.Getenv(GitTokenEnvVar),
+	}
+
+	// 1. Try to get API key and tokens from keyring
+	apiKey, err := keyring.Get(serviceName, keyringEntry(keyName, cfg.Profile))
+	if err == nil && apiKey != "" {
+		cfg.APIKey = apiKey
+		cfg.storedKeySource = KeySourceKeyring
+	}
+	if token, err := keyring.Get(serviceName, keyringEntry(gitHubTokenName, cfg.Profile)); err == nil {
+		cfg.GitHubToken = token
+	}
+	if token, err := keyring.Get(serviceName, keyringEntry(gitTokenName, cfg.Profile)); err == nil {
+		cfg.GitToken = token
+	}
+
+	// 2. Load from config file
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	fc, _, err := readFileConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	fileCfg := fc.Profiles[cfg.Profile]
+
+	// If APIKey or a token wasn't in keyring, use the one from the file
+	if cfg.APIKey == "" && fileCfg.APIKey != "" {
 		cfg.APIKey = fileCfg.APIKey
+		cfg.storedKeySource = KeySourceFile
 	}
 	if cfg.BaseURL == "" {
 		cfg.BaseURL = fileCfg.BaseURL
 	}
+	cfg.Concurrency = fileCfg.Concurrency
+	cfg.FetchConcurrency = fileCfg.FetchConcurrency
+	cfg.WebhookURL = fileCfg.WebhookURL
+	cfg.CacheTTL = fileCfg.CacheTTL
+	cfg.UpdateChannel = fileCfg.UpdateChannel
+	cfg.UpdateCheck = fileCfg.UpdateCheck
+	if cfg.GitHubToken == "" {
+		cfg.GitHubToken = fileCfg.GitHubToken
+	}
+	if cfg.GitToken == "" {
+		cfg.GitToken = fileCfg.GitToken
+	}
+	cfg.Proxy = fileCfg.Proxy
+	cfg.SSHKey = fileCfg.SSHKey
+	cfg.StaleDays = fileCfg.StaleDays
 
 	return cfg, nil
 }
 
-// Save saves the configuration. It attempts to save the API key to the keyring,
-// but falls back to saving it in the config file if necessary.
+// ListProfiles returns the sorted names of all profiles in the config file.
+// The default profile is always included.
+func ListProfiles() ([]string, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	fc, _, err := readFileConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{DefaultProfile}
+	for name := range fc.Profiles {
+		if name != DefaultProfile {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+	return names, nil
+}
+
+// Save saves the configuration to its profile. It attempts to save the API key and
+// tokens to the keyring, but falls back to saving them in the config file if necessary.
 func (cfg *Config) Save() (*SaveResult, error) {
 	result := &SaveResult{}
+	profile := ResolveProfile(cfg.Profile)
 
-	keyringErr := keyring.Set(serviceName, keyName, cfg.APIKey)
-	if keyringErr == nil {
-		result.KeyringUsed = true
+	var err error
+	result.KeyringUsed, err = saveKeyringEntry(keyName, profile, cfg.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	gitHubTokenInKeyring, err := saveKeyringEntry(gitHubTokenName, profile, cfg.GitHubToken)
+	if err != nil {
+		return nil, err
+	}
+	gitTokenInKeyring, err := saveKeyringEntry(gitTokenName, profile, cfg.GitToken)
+	if err != nil {
+		return nil, err
 	}
 
 	configPath, err := GetConfigPath()
@@ -185,18 +679,51 @@ func (cfg *Config) Save() (*SaveResult, error) {
 	}
 	result.ConfigPath = configPath
 
-	saveCfg := *cfg
+	fc, existed, err := readFileConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	saveCfg := Config{
+		APIKey:           cfg.APIKey,
+		BaseURL:          cfg.BaseURL,
+		Concurrency:      cfg.Concurrency,
+		FetchConcurrency: cfg.FetchConcurrency,
+		WebhookURL:       cfg.WebhookURL,
+		CacheTTL:         cfg.CacheTTL,
+		UpdateChannel:    cfg.UpdateChannel,
+		UpdateCheck:      cfg.UpdateCheck,
+		GitHubToken:      cfg.GitHubToken,
+		GitToken:         cfg.GitToken,
+		Proxy:            cfg.Proxy,
+		SSHKey:           cfg.SSHKey,
+		StaleDays:        cfg.StaleDays,
+	}
 	if result.KeyringUsed {
 		saveCfg.APIKey = ""
 	}
+	if gitHubTokenInKeyring {
+		saveCfg.GitHubToken = ""
+	}
+	if gitTokenInKeyring {
+		saveCfg.GitToken = ""
+	}
 
-	// Only write the file if there's actually something to save that isn't empty.
-	if saveCfg.APIKey != "" || saveCfg.BaseURL != "" {
+	// Non-default profiles are always recorded so that they can be listed, even if
+	// all of their settings are defaults or live in the keyring.
+	if saveCfg != (Config{}) || profile != DefaultProfile {
+		fc.Profiles[profile] = saveCfg
+	} else {
+		delete(fc.Profiles, profile)
+	}
+
+	// Only write the file if there's actually something to save (or to remove).
+	if len(fc.Profiles) > 0 || existed {
 		if _, err := EnsureConfigDir(); err != nil {
 			return nil, err
 		}
 
-		data, err := json.MarshalIndent(saveCfg, "", "  ") //#nosec G117
+		data, err := json.MarshalIndent(fc, "", "  ") //#nosec G117
 		if err != nil {
 			return nil, fmt.Errorf("could not marshal config: %w", err)
 		}
@@ -215,3 +742,34 @@ func (cfg *Config) SetAPIKey(key string) (*SaveResult, error) {
 	cfg.APIKey = key
 	return cfg.Save()
 }
+
+// ClearAPIKey removes the profile's stored API key from both the keyring and the config
+// file, keeping its other settings. A key set in the environment is not affected.
+func (cfg *Config) ClearAPIKey() (*SaveResult, error) {
+	cfg.APIKey = ""
+	cfg.storedKeySource = KeySourceNone
+	return cfg.Save()
+}
+
+// saveKeyringEntry stores value as the secret name of a profile in the keyring and
+// reports whether it was stored. An empty value removes any value saved earlier rather
+// than storing an empty one.
+func saveKeyringEntry(name, profile, value string) (bool, error) {
+	if value == "" {
+		return false, deleteKeyringEntry(name, profile)
+	}
+	return keyring.Set(serviceName, keyringEntry(name, profile), value) == nil, nil
+}
+
+// deleteKeyringEntry removes the secret name of a profile from the keyring. It is not an
+// error if there is no such entry, or no keyring at all, as long as nothing is left behind.
+func deleteKeyringEntry(name, profile string) error {
+	err := keyring.Delete(serviceName, keyringEntry(name, profile))
+	if err == nil || errors.Is(err, keyring.ErrNotFound) {
+		return nil
+	}
+	if value, getErr := keyring.Get(serviceName, keyringEntry(name, profile)); getErr == nil && value != "" {
+		return fmt.Errorf("could not remove %s from the keyring: %w", name, err)
+	}
+	return nil
+}
@@ -1,9 +1,14 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/zalando/go-keyring"
 )
@@ -30,7 +35,9 @@ func TestConfigLoadSave(t *testing.T) {
 	_ = os.Setenv("HOME", tmpDir)
 
 	cfg := &Config{
-		APIKey: "test-api-key",
+		APIKey:           "test-api-key",
+		StaleDays:        14,
+		FetchConcurrency: 8,
 	}
 
 	_, err = cfg.Save()
@@ -38,7 +45,7 @@ func TestConfigLoadSave(t *testing.T) {
 		t.Fatalf("failed to save config: %v", err)
 	}
 
-	loadedCfg, err := Load()
+	loadedCfg, err := Load("")
 	if err != nil {
 		t.Fatalf("failed to load config: %v", err)
 	}
@@ -46,6 +53,359 @@ func TestConfigLoadSave(t *testing.T) {
 	if loadedCfg.APIKey != "test-api-key" {
 		t.Errorf("expected APIKey 'test-api-key', got '%s'", loadedCfg.APIKey)
 	}
+	if loadedCfg.StaleDays != 14 {
+		t.Errorf("expected StaleDays 14, got %d", loadedCfg.StaleDays)
+	}
+	if loadedCfg.FetchConcurrency != 8 {
+		t.Errorf("expected FetchConcurrency 8, got %d", loadedCfg.FetchConcurrency)
+	}
+}
+
+func TestClearAPIKey(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "repoman-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	t.Setenv("HOME", tmpDir)
+	t.Setenv(APIKeyEnvVar, "")
+
+	check := func(where string) {
+		t.Helper()
+		cfg := &Config{APIKey: "test-api-key", BaseURL: "https://example.com"}
+		if _, err := cfg.Save(); err != nil {
+			t.Fatalf("failed to save config: %v", err)
+		}
+		if _, err := cfg.ClearAPIKey(); err != nil {
+			t.Fatalf("ClearAPIKey failed with the key in the %s: %v", where, err)
+		}
+		loaded, err := Load("")
+		if err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+		if loaded.APIKey != "" || loaded.GetAPIKeySource() != KeySourceNone {
+			t.Errorf("expected no API key after clearing it from the %s, got %q from %q", where, loaded.APIKey, loaded.GetAPIKeySource())
+		}
+		if loaded.BaseURL != "https://example.com" {
+			t.Errorf("expected the base URL to be kept, got %q", loaded.BaseURL)
+		}
+	}
+
+	check("keyring")
+
+	// Clearing again, with nothing stored, is not an error.
+	if _, err := (&Config{}).ClearAPIKey(); err != nil {
+		t.Errorf("ClearAPIKey with no stored key failed: %v", err)
+	}
+
+	// Without a keyring, the key is saved in, and cleared from, the config file.
+	keyring.MockInitWithError(errors.New("no keyring"))
+	defer keyring.MockInit()
+	check("config file")
+}
+
+func TestTokensInKeyring(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "repoman-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	t.Setenv("HOME", tmpDir)
+	t.Setenv(GitHubTokenEnvVar, "")
+	t.Setenv(GitTokenEnvVar, "")
+
+	cfg := &Config{GitHubToken: "secret-github-token", GitToken: "secret-git-token", Profile: "tokens"}
+	if _, err := cfg.Save(); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	configPath, err := GetConfigPath()
+	if err != nil {
+		t.Fatalf("failed to get config path: %v", err)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("failed to read config file: %v", err)
+	}
+	if strings.Contains(string(data), "secret-") {
+		t.Errorf("token was written to the config file: %s", data)
+	}
+	loaded, err := Load("tokens")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if loaded.GetGitHubToken() != "secret-github-token" {
+		t.Errorf("expected GitHub token from the keyring, got %q", loaded.GetGitHubToken())
+	}
+	if loaded.GetGitToken() != "secret-git-token" {
+		t.Errorf("expected git token from the keyring, got %q", loaded.GetGitToken())
+	}
+
+	// Saving an empty token removes it from the keyring.
+	loaded.GitHubToken = ""
+	loaded.GitToken = ""
+	if _, err := loaded.Save(); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	if _, err := keyring.Get(serviceName, keyringEntry(gitHubTokenName, "tokens")); !errors.Is(err, keyring.ErrNotFound) {
+		t.Errorf("expected GitHub token to be removed from the keyring, got %v", err)
+	}
+	if _, err := keyring.Get(serviceName, keyringEntry(gitTokenName, "tokens")); !errors.Is(err, keyring.ErrNotFound) {
+		t.Errorf("expected git token to be removed from the keyring, got %v", err)
+	}
+}
+
+func TestConfigProfiles(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "repoman-profiles-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	originalConfigDir := os.Getenv("XDG_CONFIG_HOME")
+	defer func() { _ = os.Setenv("XDG_CONFIG_HOME", originalConfigDir) }()
+	_ = os.Setenv("XDG_CONFIG_HOME", tmpDir)
+	_ = os.Setenv("HOME", tmpDir)
+
+	// Write a config file in the older single-profile format
+	configPath, err := GetConfigPath()
+	if err != nil {
+		t.Fatalf("GetConfigPath failed: %v", err)
+	}
+	if _, err := EnsureConfigDir(); err != nil {
+		t.Fatalf("EnsureConfigDir failed: %v", err)
+	}
+	if err := os.WriteFile(configPath, []byte(`{"base_url": "https://legacy.example.com"}`), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	defaultCfg, err := Load("")
+	if err != nil {
+		t.Fatalf("failed to load default profile: %v", err)
+	}
+	if defaultCfg.Profile != DefaultProfile {
+		t.Errorf("expected profile %q, got %q", DefaultProfile, defaultCfg.Profile)
+	}
+	if defaultCfg.BaseURL != "https://legacy.example.com" {
+		t.Errorf("expected legacy BaseURL to be read as default profile, got %q", defaultCfg.BaseURL)
+	}
+
+	otherCfg := &Config{
+		APIKey:  "other-api-key",
+		BaseURL: "https://other.example.com",
+		Profile: "other",
+	}
+	if _, err := otherCfg.Save(); err != nil {
+		t.Fatalf("failed to save other profile: %v", err)
+	}
+
+	loadedOther, err := Load("other")
+	if err != nil {
+		t.Fatalf("failed to load other profile: %v", err)
+	}
+	if loadedOther.APIKey != "other-api-key" || loadedOther.BaseURL != "https://other.example.com" {
+		t.Errorf("unexpected other profile: %+v", loadedOther)
+	}
+
+	// Saving another profile must not disturb the default one
+	defaultCfg, err = Load("")
+	if err != nil {
+		t.Fatalf("failed to reload default profile: %v", err)
+	}
+	if defaultCfg.BaseURL != "https://legacy.example.com" {
+		t.Errorf("expected default BaseURL to be preserved, got %q", defaultCfg.BaseURL)
+	}
+
+	// The environment variable selects the profile when no name is given
+	t.Setenv(ProfileEnvVar, "other")
+	envCfg, err := Load("")
+	if err != nil {
+		t.Fatalf("failed to load profile from env: %v", err)
+	}
+	if envCfg.Profile != "other" {
+		t.Errorf("expected profile from env to be 'other', got %q", envCfg.Profile)
+	}
+
+	profiles, err := ListProfiles()
+	if err != nil {
+		t.Fatalf("ListProfiles failed: %v", err)
+	}
+	if len(profiles) != 2 || profiles[0] != DefaultProfile || profiles[1] != "other" {
+		t.Errorf("unexpected profiles: %v", profiles)
+	}
+}
+
+func TestConfigEnvOverrides(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "repoman-env-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	t.Setenv("HOME", tmpDir)
+
+	stored := &Config{APIKey: "stored-key", BaseURL: "https://stored.example.com", GitHubToken: "stored-token", GitToken: "stored-git-token", Profile: "env-test"}
+	if _, err := stored.Save(); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	t.Setenv(APIKeyEnvVar, "env-key")
+	t.Setenv(BaseURLEnvVar, "https://env.example.com")
+	t.Setenv(GitHubTokenEnvVar, "env-token")
+	t.Setenv(GitTokenEnvVar, "env-git-token")
+
+	cfg, err := Load("env-test")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.GetAPIKey() != "env-key" {
+		t.Errorf("expected env API key, got %q", cfg.GetAPIKey())
+	}
+	if cfg.GetBaseURL() != "https://env.example.com" {
+		t.Errorf("expected env base URL, got %q", cfg.GetBaseURL())
+	}
+	if cfg.GetGitHubToken() != "env-token" {
+		t.Errorf("expected env GitHub token, got %q", cfg.GetGitHubToken())
+	}
+	if cfg.GetGitToken() != "env-git-token" {
+		t.Errorf("expected env git token, got %q", cfg.GetGitToken())
+	}
+	if cfg.GetAPIKeySource() != KeySourceEnv {
+		t.Errorf("expected key source %q, got %q", KeySourceEnv, cfg.GetAPIKeySource())
+	}
+
+	// Saving must not persist the environment values
+	if _, err := cfg.Save(); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	t.Setenv(APIKeyEnvVar, "")
+	t.Setenv(BaseURLEnvVar, "")
+	t.Setenv(GitHubTokenEnvVar, "")
+	t.Setenv(GitTokenEnvVar, "")
+
+	cfg, err = Load("env-test")
+	if err != nil {
+		t.Fatalf("failed to reload config: %v", err)
+	}
+	if cfg.GetAPIKey() != "stored-key" {
+		t.Errorf("expected stored API key after save, got %q", cfg.GetAPIKey())
+	}
+	if cfg.GetAPIKeySource() != KeySourceKeyring {
+		t.Errorf("expected key source %q, got %q", KeySourceKeyring, cfg.GetAPIKeySource())
+	}
+	if cfg.GetBaseURL() != "https://stored.example.com" {
+		t.Errorf("expected stored base URL after save, got %q", cfg.GetBaseURL())
+	}
+	if cfg.GetGitHubToken() != "stored-token" {
+		t.Errorf("expected stored GitHub token after save, got %q", cfg.GetGitHubToken())
+	}
+	if cfg.GetGitToken() != "stored-git-token" {
+		t.Errorf("expected stored git token after save, got %q", cfg.GetGitToken())
+	}
+}
+
+func TestGetCacheTTL(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"10m", 10 * time.Minute, false},
+		{"0s", 0, false},
+		{"-1m", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{CacheTTL: tt.value}
+		got, err := cfg.GetCacheTTL()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("GetCacheTTL(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("GetCacheTTL(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestGetUpdateCheckInterval(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"24h", 24 * time.Hour, false},
+		{"-1h", 0, true},
+		{"daily", 0, true},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{UpdateCheck: tt.value}
+		got, err := cfg.GetUpdateCheckInterval()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("GetUpdateCheckInterval(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("GetUpdateCheckInterval(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestGetProxy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"http://proxy.example.com:8080", "http://proxy.example.com:8080", false},
+		{"socks5://127.0.0.1:1080", "socks5://127.0.0.1:1080", false},
+		{"proxy.example.com:8080", "", true},
+		{"ftp://proxy.example.com", "", true},
+		{"http://", "", true},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{Proxy: tt.value}
+		got, err := cfg.GetProxy()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("GetProxy(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+		gotStr := ""
+		if got != nil {
+			gotStr = got.String()
+		}
+		if gotStr != tt.want {
+			t.Errorf("GetProxy(%q) = %q, want %q", tt.value, gotStr, tt.want)
+		}
+	}
+}
+
+func TestRecordUpdateCheck(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "repoman-update-check-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	t.Setenv("HOME", tmpDir)
+
+	if !LastUpdateCheck().IsZero() {
+		t.Errorf("expected zero time before any check, got %v", LastUpdateCheck())
+	}
+
+	now := time.Now().Truncate(time.Second)
+	if err := RecordUpdateCheck(now); err != nil {
+		t.Fatalf("RecordUpdateCheck failed: %v", err)
+	}
+	if got := LastUpdateCheck(); !got.Equal(now) {
+		t.Errorf("LastUpdateCheck() = %v, want %v", got, now)
+	}
 }
 
 func TestEnsureConfigDir(t *testing.T) {
@@ -105,3 +465,300 @@ func TestFindWorkspaceRoot(t *testing.T) {
 		t.Errorf("expected root %s, got %s", absTmpDir, absRoot)
 	}
 }
+
+func TestFindWorkspaceRootBoundary(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "repoman-boundary-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	// A stray workspace file above the home directory must not be found
+	if err := os.WriteFile(filepath.Join(tmpDir, workspaceFileName), []byte("{}"), 0o600); err != nil {
+		t.Fatalf("failed to create workspace file: %v", err)
+	}
+	home := filepath.Join(tmpDir, "home")
+	subDir := filepath.Join(home, "sub")
+	if err := os.MkdirAll(subDir, 0o700); err != nil {
+		t.Fatalf("failed to create subdir: %v", err)
+	}
+	t.Setenv("HOME", home)
+	t.Setenv(WorkspaceBoundaryEnvVar, "")
+
+	oldWd, _ := os.Getwd()
+	if err := os.Chdir(subDir); err != nil {
+		t.Fatalf("failed to change to subdir: %v", err)
+	}
+	defer func() { _ = os.Chdir(oldWd) }()
+
+	if root, err := FindWorkspaceRoot(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist outside any workspace, got root %q, err %v", root, err)
+	}
+
+	// A configured boundary above the stray file allows it to be found
+	t.Setenv(WorkspaceBoundaryEnvVar, filepath.Dir(tmpDir))
+	root, err := FindWorkspaceRoot()
+	if err != nil {
+		t.Fatalf("FindWorkspaceRoot failed with boundary above workspace: %v", err)
+	}
+	if !sameDir(root, tmpDir) {
+		t.Errorf("expected root %s, got %s", tmpDir, root)
+	}
+}
+
+func TestFindEnclosingClone(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "repoman-clone-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	if err := os.WriteFile(filepath.Join(tmpDir, workspaceFileName), []byte("{}"), 0o600); err != nil {
+		t.Fatalf("failed to create workspace file: %v", err)
+	}
+
+	// Simulate a student clone with a subdirectory inside the workspace
+	clone := filepath.Join(tmpDir, "student1")
+	subDir := filepath.Join(clone, "src")
+	if err := os.MkdirAll(filepath.Join(clone, ".git"), 0o700); err != nil {
+		t.Fatalf("failed to create .git dir: %v", err)
+	}
+	if err := os.MkdirAll(subDir, 0o700); err != nil {
+		t.Fatalf("failed to create subdir: %v", err)
+	}
+
+	oldWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(oldWd) }()
+
+	if err := os.Chdir(subDir); err != nil {
+		t.Fatalf("failed to change to subdir: %v", err)
+	}
+	root, err := FindWorkspaceRoot()
+	if err != nil {
+		t.Fatalf("FindWorkspaceRoot failed: %v", err)
+	}
+	found, err := FindEnclosingClone(root)
+	if err != nil {
+		t.Fatalf("FindEnclosingClone failed: %v", err)
+	}
+	absClone, _ := filepath.Abs(clone)
+	absFound, _ := filepath.Abs(found)
+	if absFound != absClone {
+		t.Errorf("expected clone %s, got %s", absClone, absFound)
+	}
+
+	// From the workspace root itself, no clone should be reported
+	if err := os.Chdir(root); err != nil {
+		t.Fatalf("failed to change to root: %v", err)
+	}
+	found, err = FindEnclosingClone(root)
+	if err != nil {
+		t.Fatalf("FindEnclosingClone failed: %v", err)
+	}
+	if found != "" {
+		t.Errorf("expected no clone at workspace root, got %s", found)
+	}
+
+	// The same holds when the root is given through a symlink and the workspace is itself
+	// inside a git repository.
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".git"), 0o700); err != nil {
+		t.Fatalf("failed to create .git dir: %v", err)
+	}
+	link := tmpDir + "-link"
+	if err := os.Symlink(tmpDir, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	defer func() { _ = os.Remove(link) }()
+	found, err = FindEnclosingClone(link)
+	if err != nil {
+		t.Fatalf("FindEnclosingClone failed: %v", err)
+	}
+	if found != "" {
+		t.Errorf("expected no clone at symlinked workspace root, got %s", found)
+	}
+}
+
+func TestLoadWorkspaceMigration(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "repoman-migrate-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	oldWd, _ := os.Getwd()
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("failed to change to temp dir: %v", err)
+	}
+	defer func() { _ = os.Chdir(oldWd) }()
+
+	// An unversioned file from an older release
+	wsFile := filepath.Join(tmpDir, workspaceFileName)
+	old := `{"course_id": "cs101", "course_name": "CS101", "assignment_id": "lab1", "assignment_name": "Lab 1"}`
+	if err := os.WriteFile(wsFile, []byte(old), 0o600); err != nil {
+		t.Fatalf("failed to create workspace file: %v", err)
+	}
+
+	wcfg, err := LoadWorkspace()
+	if err != nil {
+		t.Fatalf("LoadWorkspace failed: %v", err)
+	}
+	if wcfg.Version != WorkspaceVersion {
+		t.Errorf("expected version %d, got %d", WorkspaceVersion, wcfg.Version)
+	}
+	if wcfg.AssignmentID != "lab1" {
+		t.Errorf("expected assignment lab1, got %s", wcfg.AssignmentID)
+	}
+
+	// The file should have been rewritten in the current format
+	data, err := os.ReadFile(wsFile)
+	if err != nil {
+		t.Fatalf("failed to read workspace file: %v", err)
+	}
+	var rewritten WorkspaceConfig
+	if err := json.Unmarshal(data, &rewritten); err != nil {
+		t.Fatalf("failed to parse rewritten file: %v", err)
+	}
+	if rewritten.Version != WorkspaceVersion || rewritten.CourseName != "CS101" || rewritten.Root == "" {
+		t.Errorf("unexpected rewritten workspace config: %+v", rewritten)
+	}
+
+	// A file from a newer release must be rejected
+	future := fmt.Sprintf(`{"version": %d, "course_id": "cs101"}`, WorkspaceVersion+1)
+	if err := os.WriteFile(wsFile, []byte(future), 0o600); err != nil {
+		t.Fatalf("failed to write workspace file: %v", err)
+	}
+	if _, err := LoadWorkspace(); err == nil || !strings.Contains(err.Error(), "upgrade repoman") {
+		t.Errorf("expected upgrade error for future version, got %v", err)
+	}
+}
+
+func TestLoadWorkspaceMoved(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "repoman-moved-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	oldWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(oldWd) }()
+
+	origRoot := filepath.Join(tmpDir, "orig")
+	if err := os.MkdirAll(origRoot, 0o700); err != nil {
+		t.Fatalf("failed to create workspace dir: %v", err)
+	}
+	if err := os.Chdir(origRoot); err != nil {
+		t.Fatalf("failed to change to workspace dir: %v", err)
+	}
+
+	wcfg := &WorkspaceConfig{CourseID: "cs101", AssignmentID: "lab1"}
+	if err := wcfg.SaveWorkspace(); err != nil {
+		t.Fatalf("SaveWorkspace failed: %v", err)
+	}
+	if !filepath.IsAbs(wcfg.Root) || !sameDir(wcfg.Root, origRoot) {
+		t.Errorf("expected recorded root %s, got %s", origRoot, wcfg.Root)
+	}
+
+	// Rename the workspace directory and load from its new location
+	newRoot := filepath.Join(tmpDir, "renamed")
+	if err := os.Rename(origRoot, newRoot); err != nil {
+		t.Fatalf("failed to rename workspace: %v", err)
+	}
+	if err := os.Chdir(newRoot); err != nil {
+		t.Fatalf("failed to change to renamed workspace: %v", err)
+	}
+
+	loaded, err := LoadWorkspace()
+	if err != nil {
+		t.Fatalf("LoadWorkspace failed: %v", err)
+	}
+	if !sameDir(loaded.Root, newRoot) {
+		t.Errorf("expected root %s after move, got %s", newRoot, loaded.Root)
+	}
+
+	// The stale root should have been replaced in the file
+	data, err := os.ReadFile(filepath.Join(newRoot, workspaceFileName))
+	if err != nil {
+		t.Fatalf("failed to read workspace file: %v", err)
+	}
+	var rewritten WorkspaceConfig
+	if err := json.Unmarshal(data, &rewritten); err != nil {
+		t.Fatalf("failed to parse rewritten file: %v", err)
+	}
+	if !sameDir(rewritten.Root, newRoot) {
+		t.Errorf("expected rewritten root %s, got %s", newRoot, rewritten.Root)
+	}
+}
+
+func TestGetSSHKey(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "repoman-sshkey-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	t.Setenv("HOME", tmpDir)
+
+	for _, name := range []string{"user_key", "workspace_key"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("key"), 0o600); err != nil {
+			t.Fatalf("failed to write key: %v", err)
+		}
+	}
+
+	tests := []struct {
+		workspaceKey string
+		userKey      string
+		want         string
+		wantErr      bool
+	}{
+		{"", "", "", false},
+		{"", filepath.Join(tmpDir, "user_key"), filepath.Join(tmpDir, "user_key"), false},
+		{"workspace_key", filepath.Join(tmpDir, "user_key"), filepath.Join(tmpDir, "workspace_key"), false},
+		{"~/user_key", "", filepath.Join(tmpDir, "user_key"), false},
+		{"missing_key", "", "", true},
+	}
+
+	for _, tt := range tests {
+		wcfg := &WorkspaceConfig{SSHKey: tt.workspaceKey, Root: tmpDir}
+		got, err := wcfg.GetSSHKey(&Config{SSHKey: tt.userKey})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("GetSSHKey(%q, %q) error = %v, wantErr %v", tt.workspaceKey, tt.userKey, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("GetSSHKey(%q, %q) = %q, want %q", tt.workspaceKey, tt.userKey, got, tt.want)
+		}
+	}
+}
+
+func TestValidateLayout(t *testing.T) {
+	for _, layout := range []string{"", LayoutFlat, LayoutOwner, LayoutNested} {
+		if err := ValidateLayout(layout); err != nil {
+			t.Errorf("ValidateLayout(%q) returned error: %v", layout, err)
+		}
+	}
+	for _, layout := range []string{"Owner", "tree", "owner/"} {
+		if err := ValidateLayout(layout); err == nil {
+			t.Errorf("ValidateLayout(%q) succeeded, want error", layout)
+		}
+	}
+}
+
+func TestValidateBaseURL(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"https://crm.example.com", false},
+		{"http://localhost:5000", false},
+		{"https://example.com/repoman/", false},
+		{"crm.example.com", true},
+		{"localhost:5000", true},
+		{"ftp://crm.example.com", true},
+		{"https://", true},
+		{"https://bad host", true},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateBaseURL(tt.value); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateBaseURL(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+	}
+}
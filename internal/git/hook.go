@@ -0,0 +1,102 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// defaultHookTimeout bounds each run of a hook command, so that one that hangs (e.g.,
+// waiting for input) can't wedge the batch.
+const defaultHookTimeout = 10 * time.Minute
+
+// HookResult contains the outcome of running a hook command in a repository.
+type HookResult struct {
+	Error  error
+	Name   string
+	Output string // combined stdout and stderr
+}
+
+// ExpandHook substitutes a repository's name and absolute path for the "{name}" and
+// "{path}" placeholders in a hook command template. The values are quoted for the shell
+// the hook runs with, so a name or path containing spaces or shell metacharacters is
+// passed as a single word rather than interpreted.
+func ExpandHook(template string, r RepoInfo) string {
+	return strings.NewReplacer("{name}", hookQuote(r.Name), "{path}", hookQuote(hookPath(r))).Replace(template)
+}
+
+// hookPath returns the absolute path of a repository, falling back to its path as given.
+func hookPath(r RepoInfo) string {
+	path, err := filepath.Abs(r.Path)
+	if err != nil {
+		return r.Path
+	}
+	return path
+}
+
+// hookQuote quotes s as a single word for the shell RunHookCtx runs commands with.
+func hookQuote(s string) string {
+	if runtime.GOOS == "windows" {
+		// cmd.exe treats metacharacters inside double quotes literally, and Windows names
+		// can't contain a double quote.
+		return `"` + s + `"`
+	}
+	return shellQuote(s)
+}
+
+// RunHookCtx runs a hook command template (see ExpandHook) with the shell in the
+// repository's directory. The name and absolute path are also available to the command
+// in the REPOMAN_REPO_NAME and REPOMAN_REPO_PATH environment variables.
+// Uses the provided context for timeout/cancellation control.
+func RunHookCtx(ctx context.Context, template string, r RepoInfo) HookResult {
+	result := HookResult{Name: r.Name}
+	command := ExpandHook(template, r)
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command) //#nosec G204
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command) //#nosec G204
+	}
+	cmd.Dir = r.Path
+	cmd.Env = append(os.Environ(), "REPOMAN_REPO_NAME="+r.Name, "REPOMAN_REPO_PATH="+hookPath(r))
+
+	output, err := cmd.CombinedOutput()
+	result.Output = strings.TrimSpace(string(output))
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		result.Error = fmt.Errorf("hook %q failed: %w", command, err)
+	}
+	return result
+}
+
+// RunHookAll runs a hook command template in each of the repositories concurrently.
+// If progress is not nil, it is called with each repository and its hook error, if any,
+// once the hook finishes.
+func (m *Manager) RunHookAll(repos []RepoInfo, template string, progress ProgressFunc) []HookResult {
+	return m.RunHookAllCtx(context.Background(), repos, template, progress)
+}
+
+// RunHookAllCtx runs a hook command template in each of the repositories concurrently.
+// Uses the provided context for timeout/cancellation control.
+// If progress is not nil, it is called with each repository and its hook error, if any,
+// once the hook finishes.
+func (m *Manager) RunHookAllCtx(ctx context.Context, repos []RepoInfo, template string, progress ProgressFunc) []HookResult {
+	worker := func(ctx context.Context, r RepoInfo) HookResult {
+		ctx, cancel := context.WithTimeout(ctx, defaultHookTimeout)
+		defer cancel()
+		return RunHookCtx(ctx, template, r)
+	}
+	var report func(RepoInfo, HookResult)
+	if progress != nil {
+		report = func(r RepoInfo, result HookResult) { progress(r, result.Error) }
+	}
+	return mapRepos(ctx, m, repos, worker, report)
+}
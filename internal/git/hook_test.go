@@ -0,0 +1,103 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestExpandHook(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "repoman-hook-expand-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	r := RepoInfo{Name: "jsmith-lab1", Path: filepath.Join(tmpDir, "jsmith-lab1")}
+	got := ExpandHook("make -C {path} NAME={name} && echo {name}", r)
+	want := "make -C " + hookQuote(r.Path) + " NAME=" + hookQuote("jsmith-lab1") + " && echo " + hookQuote("jsmith-lab1")
+	if got != want {
+		t.Errorf("ExpandHook() = %q, want %q", got, want)
+	}
+}
+
+func TestRunHookQuotesPlaceholders(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands in this test use sh syntax")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "repoman-hook-quote-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	// A name with shell metacharacters must reach the command as a single word.
+	name := "it's a $(touch pwned); lab"
+	path := filepath.Join(tmpDir, name)
+	if err := os.MkdirAll(path, 0o750); err != nil {
+		t.Fatalf("failed to create repo dir: %v", err)
+	}
+
+	result := RunHookCtx(t.Context(), `printf '%s|%s' {name} {path} && test {path} = "$REPOMAN_REPO_PATH"`, RepoInfo{Name: name, Path: path})
+	if result.Error != nil {
+		t.Fatalf("expected hook to succeed, got %v (output %q)", result.Error, result.Output)
+	}
+	if want := name + "|" + path; result.Output != want {
+		t.Errorf("expected output %q, got %q", want, result.Output)
+	}
+	if _, err := os.Stat(filepath.Join(path, "pwned")); err == nil {
+		t.Error("expected the name not to be run as a command")
+	}
+}
+
+func TestRunHookAll(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands in this test use sh syntax")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "repoman-hook-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	var repos []RepoInfo
+	for _, name := range []string{"ok", "fail"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(path, 0o750); err != nil {
+			t.Fatalf("failed to create repo dir: %v", err)
+		}
+		repos = append(repos, RepoInfo{Name: name, Path: path})
+	}
+
+	template := `echo "$REPOMAN_REPO_NAME" > hook.txt && echo ran {name} && test {name} = ok`
+	var reported []string
+	results := NewManager(2).RunHookAll(repos, template, func(done RepoInfo, err error) {
+		reported = append(reported, done.Name)
+	})
+
+	if len(reported) != 2 {
+		t.Errorf("expected progress for 2 repositories, got %v", reported)
+	}
+	if results[0].Error != nil {
+		t.Errorf("expected hook to succeed in ok, got %v", results[0].Error)
+	}
+	if results[0].Output != "ran ok" {
+		t.Errorf("expected output %q, got %q", "ran ok", results[0].Output)
+	}
+	if results[1].Error == nil {
+		t.Error("expected hook to fail in fail")
+	}
+
+	// The hook runs in each repository's directory.
+	data, err := os.ReadFile(filepath.Join(tmpDir, "fail", "hook.txt"))
+	if err != nil {
+		t.Fatalf("expected hook to write hook.txt: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "fail" {
+		t.Errorf("expected REPOMAN_REPO_NAME %q, got %q", "fail", got)
+	}
+}
@@ -2,8 +2,11 @@ package git
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 )
@@ -14,6 +17,42 @@ type RepoInfo struct {
 	URL     string
 	Path    string
 	UseHTTP bool
+	// Branch, if set, is the branch cloned and kept checked out instead of the remote's
+	// default branch.
+	Branch string
+	// RecurseSubmodules initializes and updates submodules after each clone or pull.
+	RecurseSubmodules bool
+	// AbortOnConflict aborts the merge if a pull leaves the repository conflicted.
+	AbortOnConflict bool
+	// PullLFS downloads Git LFS content after each clone or pull if the repository uses
+	// LFS. It requires git-lfs; see LFSAvailable.
+	PullLFS bool
+	// MeasureSize records the repository's on-disk size in its RepoStatus.
+	MeasureSize bool
+	// CountDefaultBranch records the number of commits on the remote's default branch in
+	// its RepoStatus; see GetCommitCountOnBranchCtx.
+	CountDefaultBranch bool
+	// CountOptions selects which commits CountDefaultBranch counts.
+	CountOptions CountOptions
+	// BaselineCommits is subtracted from the default branch's commit count (e.g., the
+	// commits in the starter repository), down to a minimum of zero.
+	BaselineCommits int
+	// FixRemote points an existing clone's origin at URL if it refers to a different
+	// repository, instead of failing with ErrRemoteMismatch.
+	FixRemote bool
+	// Force pulls into an existing clone even if it has local changes, instead of
+	// skipping it with ErrLocalChanges.
+	Force bool
+	// Stash stashes an existing clone's local changes before pulling and reapplies them
+	// afterward, instead of skipping it with ErrLocalChanges.
+	Stash bool
+	// PartialClone makes a new clone a partial clone that fetches file contents from
+	// origin only when needed; existing clones are unaffected. See IsPartialClone.
+	PartialClone bool
+	// Mirror keeps a mirror of the repository at Path, a bare repository with all of its
+	// refs and no working tree, instead of a clone; see SyncMirrorCtx. Branch,
+	// RecurseSubmodules, and PullLFS are ignored.
+	Mirror bool
 }
 
 // RepoStatus contains the status of a repository.
@@ -21,10 +60,32 @@ type RepoStatus struct {
 	Error       error
 	LastCommit  time.Time
 	Name        string
+	Size        int64 // on-disk size in bytes; only set if RepoInfo.MeasureSize
 	Branch      string
 	Status      string
 	SyncState   string
 	CommitCount int
+	Duration    time.Duration // time spent checking the repository, including any fetch
+	// MismatchedRemote is the URL of the origin remote if it refers to a different
+	// repository than RepoInfo.URL; it is only checked if RepoInfo.URL is set.
+	MismatchedRemote string
+	// DefaultBranchCommits is the number of commits on the remote's default branch; it
+	// is only set if RepoInfo.CountDefaultBranch.
+	DefaultBranchCommits int
+}
+
+// GCResult contains the outcome of running garbage collection on a repository.
+type GCResult struct {
+	Error     error
+	Name      string
+	Reclaimed int64 // bytes freed in .git; may be negative if gc grew the repository
+}
+
+// PruneResult contains the outcome of pruning a repository's stale branches.
+type PruneResult struct {
+	Error  error
+	Name   string
+	Pruned []string // local branches deleted
 }
 
 const (
@@ -32,6 +93,8 @@ const (
 	StatusMissing = "Missing"
 	// StatusError indicates an error occurred while checking the repository status.
 	StatusError = "Error"
+	// StatusConflicted indicates the repository has unresolved merge conflicts.
+	StatusConflicted = "Conflicted"
 	// StateUnknown indicates the sync state of the repository is unknown.
 	StateUnknown = "Unknown"
 	// StateStale indicates the repository is behind the remote.
@@ -40,17 +103,169 @@ const (
 	StateSynced = "Synced"
 )
 
+// DiffResult contains the local changes in a repository.
+type DiffResult struct {
+	Error error
+	Name  string
+	Diff  string // empty if the repository has no unstaged changes
+}
+
+// PushResult contains the outcome of committing and pushing changes to a repository.
+type PushResult struct {
+	Error     error
+	Name      string
+	Committed bool // false if there was nothing to commit
+}
+
+// TagResult contains the outcome of tagging a repository.
+type TagResult struct {
+	Error  error
+	Name   string
+	Tagged bool // whether the tag was created, even if pushing it then failed
+	Pushed bool
+}
+
+// ResetResult contains the outcome of discarding local changes in a repository.
+type ResetResult struct {
+	Error   error
+	Name    string
+	Changed bool // whether anything was discarded
+}
+
+// ArchiveResult contains the outcome of archiving a repository.
+type ArchiveResult struct {
+	Error error
+	Name  string
+	File  string // path of the written archive
+	Size  int64  // size of the written archive in bytes
+}
+
 // Manager handles concurrent git operations.
 type Manager struct {
-	concurrency int
+	concurrency        int
+	networkConcurrency int
+	retries            int
+	repoTimeout        time.Duration
+	opTimeout          time.Duration
+}
+
+// Option configures a Manager; see NewManagerWithOptions.
+type Option func(*Manager)
+
+// WithConcurrency sets the maximum number of repositories processed at once. For status
+// checks, this is the limit on local work; see WithNetworkConcurrency for fetches.
+// Values of zero or less keep the default of 5.
+func WithConcurrency(n int) Option {
+	return func(m *Manager) {
+		if n > 0 {
+			m.concurrency = n
+		}
+	}
+}
+
+// WithNetworkConcurrency limits how many of the repositories being checked by StatusAll
+// fetch from their remotes at once, so that many repositories can be checked locally in
+// parallel without opening as many connections to the Git host (which may throttle them).
+// Time spent waiting for a fetch slot counts toward WithRepoTimeout. Values of zero or
+// less, or values not below the concurrency, leave fetches limited only by the concurrency.
+func WithNetworkConcurrency(n int) Option {
+	return func(m *Manager) {
+		if n > 0 {
+			m.networkConcurrency = n
+		}
+	}
+}
+
+// WithRetries sets how many more times a failed sync is attempted before its error is
+// reported. Only failures that may be transient are retried; see isRetryable.
+func WithRetries(n int) Option {
+	return func(m *Manager) {
+		if n > 0 {
+			m.retries = n
+		}
+	}
+}
+
+// WithRepoTimeout limits how long any single repository's operation may run.
+// A zero duration means no limit.
+func WithRepoTimeout(d time.Duration) Option {
+	return func(m *Manager) {
+		if d > 0 {
+			m.repoTimeout = d
+		}
+	}
+}
+
+// WithOpTimeout sets the deadline for each network operation (a clone, pull, or fetch).
+// Without it, a sync has no deadline beyond its context's, and a status fetch has 2 minutes.
+// Unlike WithRepoTimeout, each retry of a failed sync gets a fresh deadline.
+// A zero duration keeps the defaults.
+func WithOpTimeout(d time.Duration) Option {
+	return func(m *Manager) {
+		if d > 0 {
+			m.opTimeout = d
+		}
+	}
+}
+
+// timeout returns m's per-operation timeout if set, otherwise def.
+func (m *Manager) timeout(def time.Duration) time.Duration {
+	if m.opTimeout > 0 {
+		return m.opTimeout
+	}
+	return def
 }
 
 // NewManager creates a new Manager with the specified concurrency limit.
 func NewManager(concurrency int) *Manager {
-	if concurrency <= 0 {
-		concurrency = 5
+	return NewManagerWithOptions(WithConcurrency(concurrency))
+}
+
+// NewManagerWithOptions creates a new Manager configured by opts, applied in order.
+func NewManagerWithOptions(opts ...Option) *Manager {
+	m := &Manager{concurrency: 5}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// retryDelay is the pause before the first retry of a failed sync; each further retry
+// waits one more multiple of it.
+var retryDelay = time.Second
+
+// nonRetryable lists the errors that syncing again won't fix without the user's help.
+var nonRetryable = []error{
+	ErrMergeConflict, ErrBranchNotFound, ErrRemoteMismatch, ErrLocalChanges, ErrAuthFailed, ErrHostKey, ErrNotFound, ErrEmptyRepo,
+	context.Canceled, context.DeadlineExceeded,
+}
+
+// isRetryable reports whether a failed sync may succeed if attempted again, e.g., after a
+// connection failure.
+func isRetryable(err error) bool {
+	for _, target := range nonRetryable {
+		if errors.Is(err, target) {
+			return false
+		}
+	}
+	return true
+}
+
+// syncWithRetries runs sync, retrying failures that may be transient up to m.retries times.
+func (m *Manager) syncWithRetries(ctx context.Context, sync func() error) error {
+	err := sync()
+	for attempt := 1; attempt <= m.retries && err != nil; attempt++ {
+		if !isRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * retryDelay):
+		}
+		err = sync()
 	}
-	return &Manager{concurrency: concurrency}
+	return err
 }
 
 // SyncAll syncs all provided repositories concurrently.
@@ -60,13 +275,234 @@ func (m *Manager) SyncAll(repos []RepoInfo, progress func()) []error {
 }
 
 // SyncAllCtx syncs all provided repositories concurrently.
-// Uses the provided context for timeout/cancellation control.
+// Uses the provided context for timeout/cancellation control. If the context is canceled,
+// repositories that were not synced have an error wrapping the context's error.
 // If progress is not nil, it is called after each repository is synced.
 func (m *Manager) SyncAllCtx(ctx context.Context, repos []RepoInfo, progress func()) []error {
-	worker := func(ctx context.Context, r RepoInfo) error {
-		return SyncCtx(ctx, r.URL, r.Path, r.UseHTTP)
+	errs, _ := m.SyncAllTimedCtx(ctx, repos, ignoreResult[error](progress))
+	return errs
+}
+
+// SyncAllTimed syncs all provided repositories concurrently, as SyncAll, and also returns
+// how long each repository took to sync.
+// If progress is not nil, it is called with each repository and its error once synced.
+func (m *Manager) SyncAllTimed(repos []RepoInfo, progress ProgressFunc) ([]error, []time.Duration) {
+	return m.SyncAllTimedCtx(context.Background(), repos, progress)
+}
+
+// SyncAllTimedCtx syncs all provided repositories concurrently, as SyncAllCtx, and also
+// returns how long each repository took to sync, including any retries. Repositories that
+// were never synced because the context was canceled have a zero duration.
+// If progress is not nil, it is called with each repository and its error once synced.
+func (m *Manager) SyncAllTimedCtx(ctx context.Context, repos []RepoInfo, progress ProgressFunc) ([]error, []time.Duration) {
+	errs := make([]error, len(repos))
+	durations := make([]time.Duration, len(repos))
+	done := make([]bool, len(repos))
+	for ev := range m.SyncAllEvents(ctx, repos) {
+		if ev.Phase != PhaseDone {
+			continue
+		}
+		errs[ev.Index], durations[ev.Index], done[ev.Index] = ev.Err, ev.Duration, true
+		if progress != nil {
+			progress(ev.Repo, ev.Err)
+		}
 	}
-	return concurrentMap(ctx, m.concurrency, repos, worker, progress)
+	for i := range errs {
+		if !done[i] {
+			errs[i] = fmt.Errorf("not synced: %w", ctx.Err())
+		}
+	}
+	return errs, durations
+}
+
+// Phases of syncing a repository, as reported in a RepoEvent.
+const (
+	PhaseCloning = "cloning" // a new clone (or mirror) was started
+	PhasePulling = "pulling" // an update of an existing clone (or mirror) was started
+	PhaseDone    = "done"    // the sync finished, successfully or not
+)
+
+// RepoEvent reports progress syncing one repository in SyncAllEvents.
+type RepoEvent struct {
+	// Err is the result of the sync; it is only set for PhaseDone.
+	Err error
+	// Repo is the repository, and Index its position in the repositories being synced.
+	Repo  RepoInfo
+	Index int
+	// Phase is PhaseCloning or PhasePulling when the sync starts, then PhaseDone.
+	Phase string
+	// Duration is how long the sync took, including any retries; only set for PhaseDone.
+	Duration time.Duration
+}
+
+// SyncAllEvents syncs all provided repositories concurrently, as SyncAllCtx, reporting its
+// progress on the returned channel: each repository gets a PhaseCloning or PhasePulling
+// event when its sync starts and a PhaseDone event with its result when it finishes. The
+// channel is closed once all syncs have finished. It is buffered to hold every event, so
+// a slow receiver never holds up syncing, but it must be drained to release its events.
+// If the context is canceled, repositories that were not yet started get no events.
+func (m *Manager) SyncAllEvents(ctx context.Context, repos []RepoInfo) <-chan RepoEvent {
+	events := make(chan RepoEvent, 2*len(repos))
+	indices := make([]int, len(repos))
+	for i := range indices {
+		indices[i] = i
+	}
+	worker := func(ctx context.Context, i int) struct{} {
+		r := repos[i]
+		events <- RepoEvent{Repo: r, Index: i, Phase: syncPhase(r)}
+		start := time.Now()
+		err := m.syncRepo(ctx, r)
+		elapsed := time.Since(start)
+		logSync(ctx, r, elapsed, err)
+		events <- RepoEvent{Repo: r, Index: i, Phase: PhaseDone, Err: err, Duration: elapsed}
+		return struct{}{}
+	}
+	go func() {
+		defer close(events)
+		mapRepos(ctx, m, indices, worker, nil)
+	}()
+	return events
+}
+
+// syncPhase returns the phase syncing r starts with: PhasePulling if Path already holds
+// a clone (or mirror, for r.Mirror), and PhaseCloning otherwise.
+func syncPhase(r RepoInfo) string {
+	if r.Mirror {
+		if isBareRepo(r.Path) {
+			return PhasePulling
+		}
+		return PhaseCloning
+	}
+	if _, err := os.Stat(filepath.Join(r.Path, ".git")); err == nil {
+		return PhasePulling
+	}
+	return PhaseCloning
+}
+
+// checkRemote verifies that an existing clone's origin refers to r.URL, updating it if
+// r.FixRemote is set. It does nothing for repositories that have not been cloned.
+func checkRemote(ctx context.Context, r RepoInfo) error {
+	if _, err := os.Stat(filepath.Join(r.Path, ".git")); err != nil {
+		return nil
+	}
+	remote, err := GetRemoteURLCtx(ctx, r.Path)
+	if err != nil || SameRepo(remote, r.URL) {
+		// Without an origin, the pull reports the problem.
+		return nil
+	}
+	if !r.FixRemote {
+		return &SyncError{
+			URL: RedactURL(remote),
+			Err: fmt.Errorf("%w: origin is %s, expected %s", ErrRemoteMismatch, RedactURL(remote), RedactURL(r.URL)),
+		}
+	}
+	return SetRemoteURLCtx(ctx, r.Path, resolveURL(r.URL, r.UseHTTP))
+}
+
+// checkClean handles local changes in an existing clone before a pull: it stashes them
+// if r.Stash is set, reporting whether it did, and otherwise returns ErrLocalChanges
+// unless r.Force is set. Conflicted repositories are left for the pull to report.
+func checkClean(ctx context.Context, r RepoInfo) (stashed bool, err error) {
+	if r.Force {
+		return false, nil
+	}
+	if _, err := os.Stat(filepath.Join(r.Path, ".git")); err != nil {
+		return false, nil
+	}
+	if conflicted, err := HasConflictsCtx(ctx, r.Path); err != nil || conflicted {
+		return false, nil
+	}
+	if r.Stash {
+		return StashCtx(ctx, r.Path)
+	}
+	if clean, err := IsCleanCtx(ctx, r.Path); err == nil && !clean {
+		return false, ErrLocalChanges
+	}
+	return false, nil
+}
+
+// logSync records the outcome of syncing r to the structured logger, if one is set.
+func logSync(ctx context.Context, r RepoInfo, elapsed time.Duration, err error) {
+	if structuredLogger == nil {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("repo", r.Name),
+		slog.String("path", r.Path),
+		slog.String("url", RedactURL(r.URL)),
+		slog.Duration("duration", elapsed),
+	}
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", redactSecrets(err.Error())))
+	}
+	structuredLogger.LogAttrs(ctx, level, "sync", attrs...)
+}
+
+// syncRepo clones or pulls a single repository, then updates its submodules and LFS
+// content as requested by r.
+func (m *Manager) syncRepo(ctx context.Context, r RepoInfo) error {
+	if err := checkRemote(ctx, r); err != nil {
+		return err
+	}
+	stashed, err := checkClean(ctx, r)
+	if err != nil {
+		return err
+	}
+	err = m.updateRepo(ctx, r)
+	if !stashed {
+		return err
+	}
+
+	if err != nil {
+		if conflicted, cErr := HasConflictsCtx(ctx, r.Path); cErr == nil && conflicted {
+			// The changes can't be reapplied to a conflicted working tree.
+			return fmt.Errorf("%w; local changes are kept in the stash (see 'git stash list')", err)
+		}
+	}
+	if popErr := StashPopCtx(ctx, r.Path); popErr != nil {
+		if err != nil {
+			return fmt.Errorf("%w (%w)", err, popErr)
+		}
+		return popErr
+	}
+	return err
+}
+
+// updateRepo clones or pulls r for syncRepo, then updates its submodules and LFS content
+// as requested.
+func (m *Manager) updateRepo(ctx context.Context, r RepoInfo) error {
+	err := m.syncWithRetries(ctx, func() error {
+		opCtx := ctx
+		if m.opTimeout > 0 {
+			var cancel context.CancelFunc
+			opCtx, cancel = context.WithTimeout(ctx, m.opTimeout)
+			defer cancel()
+		}
+		return syncCtx(opCtx, r.URL, r.Path, r.UseHTTP, cloneOptions{branch: r.Branch, partial: r.PartialClone, mirror: r.Mirror})
+	})
+	if err != nil {
+		if r.AbortOnConflict && errors.Is(err, ErrMergeConflict) {
+			if abortErr := AbortMergeCtx(ctx, r.Path); abortErr != nil {
+				return fmt.Errorf("%w (failed to abort: %v)", err, abortErr)
+			}
+			return fmt.Errorf("%w (merge aborted)", err)
+		}
+		return err
+	}
+	if r.Mirror {
+		return nil
+	}
+	if r.RecurseSubmodules {
+		if err := UpdateSubmodulesCtx(ctx, r.Path); err != nil {
+			return err
+		}
+	}
+	if r.PullLFS && UsesLFS(r.Path) {
+		return LFSPullCtx(ctx, r.Path)
+	}
+	return nil
 }
 
 // StatusAll fetches status for all provided repositories concurrently.
@@ -79,13 +515,296 @@ func (m *Manager) StatusAll(repos []RepoInfo, fetch bool, progress func()) []Rep
 // Uses the provided context for timeout/cancellation control.
 // If progress is not nil, it is called after each repository's status is checked.
 func (m *Manager) StatusAllCtx(ctx context.Context, repos []RepoInfo, fetch bool, progress func()) []RepoStatus {
+	return m.StatusAllReportCtx(ctx, repos, fetch, ignoreResult[error](progress))
+}
+
+// StatusAllReport fetches status for all provided repositories concurrently, as StatusAll.
+// If progress is not nil, it is called with each repository and its status error, if any,
+// once checked.
+func (m *Manager) StatusAllReport(repos []RepoInfo, fetch bool, progress ProgressFunc) []RepoStatus {
+	return m.StatusAllReportCtx(context.Background(), repos, fetch, progress)
+}
+
+// StatusAllReportCtx fetches status for all provided repositories concurrently, as
+// StatusAllCtx. If progress is not nil, it is called with each repository and its status
+// error, if any, once checked.
+func (m *Manager) StatusAllReportCtx(ctx context.Context, repos []RepoInfo, fetch bool, progress ProgressFunc) []RepoStatus {
+	var report func(RepoInfo, RepoStatus)
+	if progress != nil {
+		report = func(r RepoInfo, status RepoStatus) { progress(r, status.Error) }
+	}
+	return m.statusAll(ctx, repos, fetch, report)
+}
+
+// StatusAllStream fetches status for all provided repositories concurrently, as StatusAll.
+// If each is not nil, it is called with each repository's status as soon as it is checked.
+func (m *Manager) StatusAllStream(repos []RepoInfo, fetch bool, each func(RepoStatus)) []RepoStatus {
+	return m.StatusAllStreamCtx(context.Background(), repos, fetch, each)
+}
+
+// StatusAllStreamCtx fetches status for all provided repositories concurrently, as
+// StatusAllCtx. If each is not nil, it is called with each repository's status as soon as
+// it is checked; calls are never concurrent.
+func (m *Manager) StatusAllStreamCtx(ctx context.Context, repos []RepoInfo, fetch bool, each func(RepoStatus)) []RepoStatus {
+	var report func(RepoInfo, RepoStatus)
+	if each != nil {
+		report = func(_ RepoInfo, status RepoStatus) { each(status) }
+	}
+	return m.statusAll(ctx, repos, fetch, report)
+}
+
+// statusAll checks the status of repos concurrently, passing each status to report, if
+// not nil, once checked.
+func (m *Manager) statusAll(ctx context.Context, repos []RepoInfo, fetch bool, report func(RepoInfo, RepoStatus)) []RepoStatus {
+	var fetchFn func(context.Context, string) error
+	if fetch {
+		fetchFn = m.limitedFetch()
+	}
 	worker := func(ctx context.Context, r RepoInfo) RepoStatus {
-		return fetchStatusWithCtx(ctx, r, fetch)
+		start := time.Now()
+		status := fetchStatusWithCtx(ctx, r, fetchFn)
+		status.Duration = time.Since(start)
+		return status
 	}
-	return concurrentMap(ctx, m.concurrency, repos, worker, progress)
+	return mapRepos(ctx, m, repos, worker, report)
+}
+
+// DiffAll gets the diff of all provided repositories concurrently, passing args to git diff.
+// If progress is not nil, it is called after each repository is processed.
+func (m *Manager) DiffAll(repos []RepoInfo, args []string, progress func()) []DiffResult {
+	return m.DiffAllCtx(context.Background(), repos, args, progress)
+}
+
+// DiffAllCtx gets the diff of all provided repositories concurrently, passing args to git diff.
+// Uses the provided context for timeout/cancellation control.
+// If progress is not nil, it is called after each repository is processed.
+func (m *Manager) DiffAllCtx(ctx context.Context, repos []RepoInfo, args []string, progress func()) []DiffResult {
+	worker := func(ctx context.Context, r RepoInfo) DiffResult {
+		diff, err := GetDiffCtx(ctx, r.Path, args...)
+		return DiffResult{Name: r.Name, Diff: diff, Error: err}
+	}
+	return mapRepos(ctx, m, repos, worker, ignoreResult[DiffResult](progress))
+}
+
+// CommitAndPushAll commits the given paths in all provided repositories and pushes them,
+// concurrently. If progress is not nil, it is called after each repository is processed.
+func (m *Manager) CommitAndPushAll(repos []RepoInfo, message string, paths []string, progress func()) []PushResult {
+	return m.CommitAndPushAllCtx(context.Background(), repos, message, paths, progress)
+}
+
+// CommitAndPushAllCtx commits the given paths in all provided repositories and pushes them,
+// concurrently. A repository with nothing to commit is still pushed, so that commits left
+// unpushed by an earlier failure are sent.
+// Uses the provided context for timeout/cancellation control.
+// If progress is not nil, it is called after each repository is processed.
+func (m *Manager) CommitAndPushAllCtx(ctx context.Context, repos []RepoInfo, message string, paths []string, progress func()) []PushResult {
+	worker := func(ctx context.Context, r RepoInfo) PushResult {
+		result := PushResult{Name: r.Name}
+		err := CommitAllCtx(ctx, r.Path, message, paths...)
+		if err != nil && !errors.Is(err, ErrNothingToCommit) {
+			result.Error = err
+			return result
+		}
+		result.Committed = err == nil
+		result.Error = PushCtx(ctx, r.Path)
+		return result
+	}
+	return mapRepos(ctx, m, repos, worker, ignoreResult[PushResult](progress))
+}
+
+// TagAll tags HEAD in all provided repositories concurrently, pushing each tag to origin
+// if push is true. If progress is not nil, it is called after each repository is processed.
+func (m *Manager) TagAll(repos []RepoInfo, name, message string, push bool, progress func()) []TagResult {
+	return m.TagAllCtx(context.Background(), repos, name, message, push, progress)
+}
+
+// TagAllCtx tags HEAD in all provided repositories concurrently, as in TagCtx, pushing
+// each tag to origin if push is true. A repository that already has the tag is reported
+// with ErrTagExists and not pushed.
+// Uses the provided context for timeout/cancellation control.
+// If progress is not nil, it is called after each repository is processed.
+func (m *Manager) TagAllCtx(ctx context.Context, repos []RepoInfo, name, message string, push bool, progress func()) []TagResult {
+	worker := func(ctx context.Context, r RepoInfo) TagResult {
+		result := TagResult{Name: r.Name}
+		if err := TagCtx(ctx, r.Path, name, message); err != nil {
+			result.Error = err
+			return result
+		}
+		result.Tagged = true
+		if push {
+			result.Error = PushTagCtx(ctx, r.Path, name)
+			result.Pushed = result.Error == nil
+		}
+		return result
+	}
+	return mapRepos(ctx, m, repos, worker, ignoreResult[TagResult](progress))
+}
+
+// ResetAll discards local changes in all provided repositories concurrently.
+// If progress is not nil, it is called after each repository is processed.
+func (m *Manager) ResetAll(repos []RepoInfo, ref string, clean bool, progress func()) []ResetResult {
+	return m.ResetAllCtx(context.Background(), repos, ref, clean, progress)
+}
+
+// ResetAllCtx discards local changes in all provided repositories concurrently, resetting
+// each to ref as in ResetHardCtx. If clean is true, untracked files are removed as well.
+// Uses the provided context for timeout/cancellation control.
+// If progress is not nil, it is called after each repository is processed.
+func (m *Manager) ResetAllCtx(ctx context.Context, repos []RepoInfo, ref string, clean bool, progress func()) []ResetResult {
+	worker := func(ctx context.Context, r RepoInfo) ResetResult {
+		result := ResetResult{Name: r.Name}
+		before, err := localState(ctx, r.Path, clean)
+		if err != nil {
+			result.Error = err
+			return result
+		}
+
+		// An empty repository has nothing to reset to.
+		if count, err := GetCommitCountCtx(ctx, r.Path); err != nil || count > 0 {
+			if err := ResetHardCtx(ctx, r.Path, ref); err != nil {
+				result.Error = err
+				return result
+			}
+		}
+		if clean {
+			if err := CleanCtx(ctx, r.Path); err != nil {
+				result.Error = err
+				return result
+			}
+		}
+
+		after, err := localState(ctx, r.Path, clean)
+		result.Changed = err != nil || after != before
+		return result
+	}
+	return mapRepos(ctx, m, repos, worker, ignoreResult[ResetResult](progress))
+}
+
+// GCAll runs garbage collection on all provided repositories concurrently.
+// If progress is not nil, it is called after each repository is processed.
+func (m *Manager) GCAll(repos []RepoInfo, progress func()) []GCResult {
+	return m.GCAllCtx(context.Background(), repos, progress)
 }
 
-func fetchStatusWithCtx(ctx context.Context, r RepoInfo, fetch bool) RepoStatus {
+// GCAllCtx runs garbage collection on all provided repositories concurrently.
+// Repositories that are locked or have an operation in progress are skipped, with an
+// error wrapping ErrOperationInProgress.
+// Uses the provided context for timeout/cancellation control.
+// If progress is not nil, it is called after each repository is processed.
+func (m *Manager) GCAllCtx(ctx context.Context, repos []RepoInfo, progress func()) []GCResult {
+	worker := func(ctx context.Context, r RepoInfo) GCResult {
+		result := GCResult{Name: r.Name}
+		gitDir := filepath.Join(r.Path, ".git")
+		before, sizeErr := dirSize(gitDir)
+		if err := GCCtx(ctx, r.Path); err != nil {
+			result.Error = err
+			return result
+		}
+		if sizeErr == nil {
+			if after, err := dirSize(gitDir); err == nil {
+				result.Reclaimed = before - after
+			}
+		}
+		return result
+	}
+	return mapRepos(ctx, m, repos, worker, ignoreResult[GCResult](progress))
+}
+
+// PruneBranchesAll prunes stale branches in all provided repositories concurrently; see
+// PruneBranchesCtx. If progress is not nil, it is called after each repository is processed.
+func (m *Manager) PruneBranchesAll(repos []RepoInfo, progress func()) []PruneResult {
+	return m.PruneBranchesAllCtx(context.Background(), repos, progress)
+}
+
+// PruneBranchesAllCtx prunes stale branches in all provided repositories concurrently; see
+// PruneBranchesCtx.
+// Uses the provided context for timeout/cancellation control.
+// If progress is not nil, it is called after each repository is processed.
+func (m *Manager) PruneBranchesAllCtx(ctx context.Context, repos []RepoInfo, progress func()) []PruneResult {
+	worker := func(ctx context.Context, r RepoInfo) PruneResult {
+		opCtx, cancel := context.WithTimeout(ctx, m.timeout(defaultPullTimeout))
+		defer cancel()
+		pruned, err := PruneBranchesCtx(opCtx, r.Path)
+		return PruneResult{Name: r.Name, Pruned: pruned, Error: err}
+	}
+	return mapRepos(ctx, m, repos, worker, ignoreResult[PruneResult](progress))
+}
+
+// ArchiveAll writes a zip archive of each provided repository at ref (HEAD if empty) to
+// <outDir>/<name>.zip, concurrently. If progress is not nil, it is called after each
+// repository is processed.
+func (m *Manager) ArchiveAll(repos []RepoInfo, ref, outDir string, progress func()) []ArchiveResult {
+	return m.ArchiveAllCtx(context.Background(), repos, ref, outDir, progress)
+}
+
+// ArchiveAllCtx writes a zip archive of each provided repository at ref (HEAD if empty) to
+// <outDir>/<name>.zip, concurrently. Each archive is written to a temporary file first, so
+// a failure leaves any existing archive in place.
+// Uses the provided context for timeout/cancellation control.
+// If progress is not nil, it is called after each repository is processed.
+func (m *Manager) ArchiveAllCtx(ctx context.Context, repos []RepoInfo, ref, outDir string, progress func()) []ArchiveResult {
+	worker := func(ctx context.Context, r RepoInfo) ArchiveResult {
+		result := ArchiveResult{Name: r.Name, File: filepath.Join(outDir, r.Name+".zip")}
+		if err := archiveAtomic(ctx, r.Path, ref, result.File); err != nil {
+			result.Error = err
+			return result
+		}
+		if info, err := os.Stat(result.File); err == nil {
+			result.Size = info.Size()
+		}
+		return result
+	}
+	return mapRepos(ctx, m, repos, worker, ignoreResult[ArchiveResult](progress))
+}
+
+// archiveAtomic writes an archive like ArchiveCtx, but into a temporary directory beside
+// outFile, renaming it into place only once it is complete.
+func archiveAtomic(ctx context.Context, path, ref, outFile string) error {
+	tmpDir, err := os.MkdirTemp(filepath.Dir(outFile), "."+filepath.Base(outFile)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary archive directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	tmpFile := filepath.Join(tmpDir, filepath.Base(outFile))
+	if err := ArchiveCtx(ctx, path, ref, tmpFile); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpFile, outFile); err != nil {
+		return fmt.Errorf("failed to move archive into place: %w", err)
+	}
+	return nil
+}
+
+// fetchRepoCtx fetches a repository for limitedFetch; tests replace it to observe fetches.
+var fetchRepoCtx = FetchCtx
+
+// limitedFetch returns a function that fetches a repository with m's per-operation timeout,
+// allowing at most m's network concurrency fetches at once.
+func (m *Manager) limitedFetch() func(context.Context, string) error {
+	timeout := m.timeout(defaultPullTimeout)
+	var slots chan struct{}
+	if m.networkConcurrency > 0 && m.networkConcurrency < m.concurrency {
+		slots = make(chan struct{}, m.networkConcurrency)
+	}
+	return func(ctx context.Context, path string) error {
+		if slots != nil {
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return fetchRepoCtx(ctx, path)
+	}
+}
+
+// fetchStatusWithCtx checks the status of a repository, first fetching from its remote
+// with fetch unless it is nil.
+func fetchStatusWithCtx(ctx context.Context, r RepoInfo, fetch func(context.Context, string) error) RepoStatus {
 	status := RepoStatus{Name: r.Name}
 
 	if _, err := os.Stat(r.Path); err != nil {
@@ -99,22 +818,20 @@ func fetchStatusWithCtx(ctx context.Context, r RepoInfo, fetch bool) RepoStatus
 	}
 
 	var fetchErr error
-	if fetch {
-		fetchCtx, fetchCancel := context.WithTimeout(ctx, defaultPullTimeout)
-		fetchErr = FetchCtx(fetchCtx, r.Path)
-		fetchCancel()
+	if fetch != nil {
+		fetchErr = fetch(ctx, r.Path)
 	}
 
-	branch, repoSummary, err := GetStatusCtx(ctx, r.Path)
-	status.Branch = branch
+	state, err := GetRepoStateCtx(ctx, r.Path)
+	status.Branch = state.Branch
 	if err != nil {
 		status.Status = StatusError
 		status.Error = err
 		return status
 	}
-	status.Status = repoSummary
+	status.Status = state.Summary()
 
-	syncState, syncErr := GetSyncStateCtx(ctx, r.Path)
+	syncState, syncErr := state.SyncState()
 	if syncErr != nil {
 		status.SyncState = StateUnknown
 		if status.Error == nil {
@@ -133,24 +850,77 @@ func fetchStatusWithCtx(ctx context.Context, r RepoInfo, fetch bool) RepoStatus
 		}
 	}
 
-	lastCommit, err := GetLastCommitTimeCtx(ctx, r.Path)
-	status.LastCommit = lastCommit
-	if err != nil && status.Error == nil {
-		status.Error = err
+	if !state.Empty {
+		lastCommit, err := GetLastCommitTimeCtx(ctx, r.Path)
+		status.LastCommit = lastCommit
+		if err != nil && status.Error == nil {
+			status.Error = err
+		}
+
+		commitCount, err := GetCommitCountCtx(ctx, r.Path)
+		status.CommitCount = commitCount
+		if err != nil && status.Error == nil {
+			status.Error = err
+		}
+
+		if r.CountDefaultBranch {
+			count, err := GetCommitCountWithOptionsCtx(ctx, r.Path, "", r.CountOptions)
+			status.DefaultBranchCommits = max(count-r.BaselineCommits, 0)
+			if err != nil && status.Error == nil {
+				status.Error = err
+			}
+		}
 	}
 
-	commitCount, err := GetCommitCountCtx(ctx, r.Path)
-	status.CommitCount = commitCount
-	if err != nil && status.Error == nil {
-		status.Error = err
+	if r.URL != "" {
+		if remote, err := GetRemoteURLCtx(ctx, r.Path); err == nil && !SameRepo(remote, r.URL) {
+			status.MismatchedRemote = RedactURL(remote)
+		}
+	}
+
+	if r.MeasureSize {
+		size, err := GetRepoSizeCtx(ctx, r.Path)
+		status.Size = size
+		if err != nil && status.Error == nil {
+			status.Error = fmt.Errorf("failed to measure size: %w", err)
+		}
 	}
 
 	return status
 }
 
+// ProgressFunc is called after each repository is processed, with the repository and the
+// error it failed with, if any. Calls are serialized, so it need not be safe for
+// concurrent use.
+type ProgressFunc func(done RepoInfo, err error)
+
+// ignoreResult adapts a progress callback that takes no arguments for use with a function
+// reporting each repository and its result. It returns nil if progress is nil.
+func ignoreResult[R any](progress func()) func(RepoInfo, R) {
+	if progress == nil {
+		return nil
+	}
+	return func(RepoInfo, R) { progress() }
+}
+
+// mapRepos runs worker over repos (or their indices) with m's concurrency limit, giving
+// each call its own deadline if m has a per-repository timeout.
+func mapRepos[T any, R any](ctx context.Context, m *Manager, repos []T, worker func(context.Context, T) R, progress func(T, R)) []R {
+	if m.repoTimeout > 0 {
+		inner := worker
+		worker = func(ctx context.Context, r T) R {
+			ctx, cancel := context.WithTimeout(ctx, m.repoTimeout)
+			defer cancel()
+			return inner(ctx, r)
+		}
+	}
+	return concurrentMap(ctx, m.concurrency, repos, worker, progress)
+}
+
 // concurrentMap transforms a slice of T into a slice of R concurrently using a worker pool.
-// It respects context cancellation and will stop early if the context is canceled.
-func concurrentMap[T any, R any](ctx context.Context, concurrency int, items []T, worker func(context.Context, T) R, progress func()) []R {
+// It respects context cancellation and will stop early if the context is canceled: items
+// not yet started are skipped, leaving the zero value of R as their result.
+func concurrentMap[T any, R any](ctx context.Context, concurrency int, items []T, worker func(context.Context, T) R, progress func(T, R)) []R {
 	results := make([]R, len(items))
 	if len(items) == 0 {
 		return results
@@ -183,14 +953,14 @@ func concurrentMap[T any, R any](ctx context.Context, concurrency int, items []T
 				case <-ctx.Done():
 					return
 				case t, ok := <-tasks:
-					if !ok {
+					if !ok || ctx.Err() != nil {
 						return
 					}
 					res := worker(ctx, t.item)
 					results[t.index] = res
 					if progress != nil {
 						mu.Lock()
-						progress()
+						progress(t.item, res)
 						mu.Unlock()
 					}
 				}
@@ -2,6 +2,12 @@
 package ui
 
 import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/pterm/pterm"
 )
 
@@ -15,18 +21,215 @@ var (
 	// Error is the style for error messages
 	Error = pterm.Error
 
-	// Info is the style for info messages
-	Info = pterm.NewRGB(80, 180, 200)
+	// Info is the style for info messages; they are not printed in quiet mode
+	Info = InfoStyle{pterm.NewRGB(80, 180, 200)}
 
-	// Dim is the style for secondary/dimmed text
-	Dim = pterm.FgGray.ToStyle()
+	// Dim is the style for secondary/dimmed text; it is not printed in quiet mode
+	Dim = InfoStyle{pterm.FgGray.ToStyle()}
 
 	// Progressbar sets up a styled progress bar
 	Progressbar = pterm.DefaultProgressbar.WithBarStyle(pterm.FgGray.ToStyle()).WithBarFiller(pterm.Gray("."))
 )
 
-// PrintHeader prints a header at the start of the program
+// Verbosity is the amount of output to print.
+type Verbosity int
+
+const (
+	// VerbosityQuiet prints only results, warnings, and errors.
+	VerbosityQuiet Verbosity = iota
+	// VerbosityNormal additionally prints headers, informational messages, and progress bars.
+	VerbosityNormal
+	// VerbosityVerbose additionally logs each git command and API request.
+	VerbosityVerbose
+)
+
+var verbosity = VerbosityNormal
+
+// SetVerbosity sets the amount of output to print. It must be called before any output.
+func SetVerbosity(v Verbosity) {
+	verbosity = v
+	if v == VerbosityQuiet {
+		Progressbar = Progressbar.WithWriter(io.Discard)
+	}
+}
+
+// IsQuiet reports whether informational output is suppressed.
+func IsQuiet() bool {
+	return verbosity <= VerbosityQuiet
+}
+
+// IsVerbose reports whether git commands and API requests should be logged.
+func IsVerbose() bool {
+	return verbosity >= VerbosityVerbose
+}
+
+// InfoStyle is a style for informational output. Its Print methods print nothing in
+// quiet mode, while its Sprint methods always return the styled text.
+type InfoStyle struct {
+	style interface{ Sprint(a ...any) string }
+}
+
+// Sprint returns the styled text.
+func (s InfoStyle) Sprint(a ...any) string {
+	return s.style.Sprint(a...)
+}
+
+// Sprintf returns the styled, formatted text.
+func (s InfoStyle) Sprintf(format string, a ...any) string {
+	return s.style.Sprint(fmt.Sprintf(format, a...))
+}
+
+// Sprintln returns the styled text followed by a newline.
+func (s InfoStyle) Sprintln(a ...any) string {
+	return s.style.Sprint(fmt.Sprintln(a...))
+}
+
+// Print prints the styled text unless in quiet mode.
+func (s InfoStyle) Print(a ...any) {
+	if !IsQuiet() {
+		pterm.Print(s.Sprint(a...))
+	}
+}
+
+// Printf prints the styled, formatted text unless in quiet mode.
+func (s InfoStyle) Printf(format string, a ...any) {
+	if !IsQuiet() {
+		pterm.Print(s.Sprintf(format, a...))
+	}
+}
+
+// Println prints the styled text followed by a newline unless in quiet mode.
+func (s InfoStyle) Println(a ...any) {
+	if !IsQuiet() {
+		pterm.Print(s.Sprintln(a...))
+	}
+}
+
+// Outcome is how an item tracked by a Progress turned out.
+type Outcome int
+
+const (
+	// OutcomeSucceeded is an item that completed successfully.
+	OutcomeSucceeded Outcome = iota
+	// OutcomeFailed is an item that failed.
+	OutcomeFailed
+	// OutcomeSkipped is an item that was left alone, neither succeeding nor failing.
+	OutcomeSkipped
+)
+
+// Progress is a progress bar for work whose items complete concurrently. Along with the
+// count and elapsed time, it shows the completion rate and an estimated time remaining,
+// both computed from the items completed so far rather than assuming serial execution.
+// It is safe for concurrent use.
+type Progress struct {
+	mu     sync.Mutex
+	bar    *pterm.ProgressbarPrinter
+	title  string
+	start  time.Time
+	done   int
+	total  int
+	counts [3]int // by Outcome
+	tally  bool
+}
+
+// StartProgress starts a progress bar for total items with the given title.
+func StartProgress(total int, title string) *Progress {
+	bar, _ := Progressbar.WithTotal(total).WithTitle(title).Start()
+	return &Progress{bar: bar, title: title, start: time.Now(), total: total}
+}
+
+// WithTally makes the bar show running counts of the items that succeeded, failed, and
+// were skipped, e.g. "40 ✓ / 2 ✗", and returns it.
+func (p *Progress) WithTally() *Progress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tally = true
+	return p
+}
+
+// Increment records that one more item has completed.
+func (p *Progress) Increment() {
+	p.IncrementOutcome("", OutcomeSucceeded)
+}
+
+// maxItemWidth limits the length of the item name shown by IncrementItem, so that long
+// names don't push the bar off the line.
+const maxItemWidth = 24
+
+// IncrementItem records that the named item has completed, showing it after the stats as
+// the most recently completed item, in red if it failed.
+func (p *Progress) IncrementItem(name string, failed bool) {
+	outcome := OutcomeSucceeded
+	if failed {
+		outcome = OutcomeFailed
+	}
+	p.IncrementOutcome(name, outcome)
+}
+
+// IncrementOutcome is IncrementItem for an item with the given outcome, which is counted
+// in the tally if it is shown (see WithTally).
+func (p *Progress) IncrementOutcome(name string, outcome Outcome) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done++
+	p.counts[outcome]++
+	title := p.title
+	if p.tally {
+		title += " " + p.tallyString()
+	}
+	title += " " + Dim.Sprint(progressStats(p.done, p.total, time.Since(p.start)))
+	if name != "" {
+		if runes := []rune(name); len(runes) > maxItemWidth {
+			name = string(runes[:maxItemWidth-1]) + "…"
+		}
+		switch outcome {
+		case OutcomeFailed:
+			name = pterm.Red(name)
+		case OutcomeSkipped:
+			name = pterm.Yellow(name)
+		default:
+			name = Dim.Sprint(name)
+		}
+		title += " " + name
+	}
+	p.bar.UpdateTitle(strings.TrimSpace(title))
+	p.bar.Increment()
+}
+
+// tallyString formats the counts of each outcome so far, leaving out failures and skips
+// until there are some.
+func (p *Progress) tallyString() string {
+	parts := []string{pterm.Green(fmt.Sprintf("%d ✓", p.counts[OutcomeSucceeded]))}
+	if n := p.counts[OutcomeFailed]; n > 0 {
+		parts = append(parts, pterm.Red(fmt.Sprintf("%d ✗", n)))
+	}
+	if n := p.counts[OutcomeSkipped]; n > 0 {
+		parts = append(parts, pterm.Yellow(fmt.Sprintf("%d skipped", n)))
+	}
+	return strings.Join(parts, " / ")
+}
+
+// progressStats formats the completion rate and, while items remain, the estimated time
+// remaining, e.g. "2.5/s, ETA 1m20s".
+func progressStats(done, total int, elapsed time.Duration) string {
+	if done <= 0 || elapsed <= 0 {
+		return ""
+	}
+	rate := float64(done) / elapsed.Seconds()
+	stats := fmt.Sprintf("%.1f/s", rate)
+	if remaining := total - done; remaining > 0 {
+		eta := time.Duration(float64(remaining) / rate * float64(time.Second))
+		stats += ", ETA " + eta.Round(time.Second).String()
+	}
+	return stats
+}
+
+// PrintHeader prints a header at the start of the program, unless in quiet mode
 func PrintHeader(title string) {
+	if IsQuiet() {
+		return
+	}
 	RepomanTitle := pterm.NewRGB(60, 140, 250)
 	RepomanTitle.Print("Repoman: ")
 	pterm.Println(title)
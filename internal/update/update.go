@@ -2,11 +2,18 @@
 package update
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"runtime"
+	"strings"
 
 	"github.com/minio/selfupdate"
 	"github.com/pterm/pterm"
@@ -19,10 +26,40 @@ const (
 	githubRepo  = "repoman"
 )
 
+// releasesURL is the GitHub API endpoint listing the repository's releases.
+// It is a variable so that tests can point it at a local server.
+var releasesURL = fmt.Sprintf("https://api.github.com/repos/%s/%s/releases", githubOwner, githubRepo)
+
+// Channel selects which releases are considered when checking for updates.
+type Channel string
+
+const (
+	// ChannelStable considers only full releases.
+	ChannelStable Channel = "stable"
+	// ChannelBeta also considers pre-releases such as release candidates.
+	ChannelBeta Channel = "beta"
+)
+
+// ParseChannel converts a channel name to a Channel. An empty name selects ChannelStable.
+func ParseChannel(name string) (Channel, error) {
+	switch Channel(name) {
+	case "", ChannelStable:
+		return ChannelStable, nil
+	case ChannelBeta:
+		return ChannelBeta, nil
+	}
+	return "", fmt.Errorf("unknown update channel %q: must be %q or %q", name, ChannelStable, ChannelBeta)
+}
+
 // Release represents a GitHub release.
 type Release struct {
-	TagName string  `json:"tag_name"`
-	Assets  []Asset `json:"assets"`
+	TagName    string  `json:"tag_name"`
+	Name       string  `json:"name"`
+	Body       string  `json:"body"`
+	HTMLURL    string  `json:"html_url"`
+	Draft      bool    `json:"draft"`
+	Prerelease bool    `json:"prerelease"`
+	Assets     []Asset `json:"assets"`
 }
 
 // Asset represents a GitHub release asset.
@@ -31,57 +68,214 @@ type Asset struct {
 	BrowserDownloadURL string `json:"browser_download_url"`
 }
 
-// CheckAndUpdate checks for a new version on GitHub and performs the update if available.
-func CheckAndUpdate(currentVersion string) (bool, error) {
-	resp, err := http.Get(fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", githubOwner, githubRepo))
+// ErrRateLimited is returned when GitHub refuses an update check because the API rate
+// limit has been exceeded. Unauthenticated requests share a low limit per IP address.
+var ErrRateLimited = errors.New("GitHub API rate limit exceeded")
+
+// ErrDevBuild is returned when the running binary is a development build, which has no
+// version to compare against releases.
+var ErrDevBuild = errors.New("development build")
+
+// Check fetches the newest release on the given channel from GitHub and reports whether
+// it is strictly newer than currentVersion. If there are no matching releases, it returns
+// an empty Release and false. If token is not empty, it is used to authenticate with the
+// GitHub API, which raises the rate limit.
+func Check(currentVersion string, channel Channel, token string) (Release, bool, error) {
+	return CheckCtx(context.Background(), currentVersion, channel, token)
+}
+
+// CheckCtx fetches the newest release on the given channel and reports whether it is
+// strictly newer than currentVersion.
+// Uses the provided context for timeout/cancellation control.
+func CheckCtx(ctx context.Context, currentVersion string, channel Channel, token string) (Release, bool, error) {
+	if currentVersion == "dev" {
+		return Release{}, false, ErrDevBuild
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, releasesURL, nil)
 	if err != nil {
-		return false, fmt.Errorf("failed to check for updates: %w", err)
+		return Release{}, false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Release{}, false, fmt.Errorf("failed to check for updates: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return false, nil // No releases yet
+		return Release{}, false, nil // No releases yet
+	}
+	if isRateLimited(resp) {
+		return Release{}, false, ErrRateLimited
 	}
 	if resp.StatusCode != http.StatusOK {
-		return false, fmt.Errorf("unexpected status code checking for updates: %d", resp.StatusCode)
+		return Release{}, false, fmt.Errorf("unexpected status code checking for updates: %d", resp.StatusCode)
+	}
+
+	var releases []Release
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return Release{}, false, fmt.Errorf("failed to decode release info: %w", err)
+	}
+
+	release, ok := newestRelease(releases, channel)
+	if !ok {
+		return Release{}, false, nil
+	}
+
+	newer, err := isNewer(release.TagName, currentVersion)
+	if err != nil {
+		return release, false, fmt.Errorf("failed to compare versions: %w", err)
 	}
+	return release, newer, nil
+}
 
-	var release Release
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return false, fmt.Errorf("failed to decode release info: %w", err)
+// isRateLimited reports whether a GitHub API response indicates an exhausted rate limit.
+// GitHub signals this with a 403 and no remaining requests, or with a 429.
+func isRateLimited(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
 	}
+	return resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0"
+}
 
-	if release.TagName == currentVersion || release.TagName == fmt.Sprintf("v%s", currentVersion) {
-		return false, nil // Up to date
+// newestRelease returns the release with the highest version on the given channel.
+// Drafts and releases whose tags are not valid versions are ignored.
+func newestRelease(releases []Release, channel Channel) (Release, bool) {
+	var (
+		best    Release
+		bestVer semver
+		found   bool
+	)
+	for _, r := range releases {
+		if r.Draft || (r.Prerelease && channel != ChannelBeta) {
+			continue
+		}
+		v, err := parseVersion(r.TagName)
+		if err != nil {
+			continue
+		}
+		if !found || v.compare(bestVer) > 0 {
+			best, bestVer, found = r, v, true
+		}
 	}
+	return best, found
+}
 
-	// Find the asset for the current OS and Arch
-	// Expecting naming like repoman-linux-amd64 or repoman-windows-amd64.exe
+// Apply downloads the binary for the current OS and Arch from the release and replaces
+// the running executable with it.
+func Apply(release Release) error {
+	asset, err := findAsset(release, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return err
+	}
+
+	checksum, err := fetchChecksum(release, asset.Name)
+	if err != nil {
+		return fmt.Errorf("failed to fetch checksum: %w", err)
+	}
+
+	if err := doUpdate(asset.BrowserDownloadURL, checksum); err != nil {
+		return fmt.Errorf("failed to apply update: %w", err)
+	}
+	return nil
+}
+
+// CheckAndUpdate checks for a new version on GitHub and performs the update if the newest
+// release on the given channel is strictly newer than currentVersion.
+func CheckAndUpdate(currentVersion string, channel Channel, token string) (bool, error) {
+	release, hasUpdate, err := Check(currentVersion, channel, token)
+	if err != nil || !hasUpdate {
+		return false, err
+	}
+
+	if err := Apply(release); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// findAsset returns the release asset for the given OS and Arch.
+// Assets are expected to be named like repoman-linux-amd64 or repoman-windows-amd64.exe.
+func findAsset(release Release, goos, goarch string) (Asset, error) {
 	extension := ""
-	if runtime.GOOS == "windows" {
+	if goos == "windows" {
 		extension = ".exe"
 	}
-	targetAsset := fmt.Sprintf("repoman-%s-%s%s", runtime.GOOS, runtime.GOARCH, extension)
-	var downloadURL string
+	targetAsset := fmt.Sprintf("repoman-%s-%s%s", goos, goarch, extension)
 	for _, asset := range release.Assets {
 		if asset.Name == targetAsset {
-			downloadURL = asset.BrowserDownloadURL
-			break
+			return asset, nil
+		}
+	}
+	return Asset{}, fmt.Errorf("no suitable asset found in latest release for %s", targetAsset)
+}
+
+// fetchChecksum downloads the expected SHA-256 of the named asset from the release.
+// It looks for a <name>.sha256 asset first, then a checksums.txt listing. If the release
+// publishes neither, it returns nil and the update is applied without verification.
+func fetchChecksum(release Release, name string) ([]byte, error) {
+	for _, candidate := range []string{name + ".sha256", "checksums.txt"} {
+		for _, asset := range release.Assets {
+			if asset.Name != candidate {
+				continue
+			}
+			data, err := download(asset.BrowserDownloadURL)
+			if err != nil {
+				return nil, err
+			}
+			return parseChecksum(data, name)
 		}
 	}
+	return nil, nil
+}
 
-	if downloadURL == "" {
-		return false, fmt.Errorf("no suitable asset found in latest release for %s", targetAsset)
+// parseChecksum extracts the SHA-256 for name from checksum file contents. Each line is
+// expected in sha256sum format ("<hex>  <name>"); a line holding only a hash also matches,
+// as is common for single-file .sha256 assets.
+func parseChecksum(data []byte, name string) ([]byte, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 1 && strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		sum, err := hex.DecodeString(fields[0])
+		if err != nil || len(sum) != sha256.Size {
+			return nil, fmt.Errorf("invalid checksum for %s", name)
+		}
+		return sum, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
 	}
+	return nil, fmt.Errorf("no checksum listed for %s", name)
+}
 
-	if err := doUpdate(downloadURL); err != nil {
-		return false, fmt.Errorf("failed to apply update: %w", err)
+func download(url string) ([]byte, error) {
+	// #nosec G107
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
 	}
+	defer func() { _ = resp.Body.Close() }()
 
-	return true, nil
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code downloading %s: %d", url, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
 }
 
-func doUpdate(url string) error {
+// doUpdate downloads the binary at url and replaces the running executable with it.
+// If checksum is not nil, the download is verified against it before being applied.
+func doUpdate(url string, checksum []byte) error {
 	// #nosec G107
 	resp, err := http.Get(url)
 	if err != nil {
@@ -98,7 +292,8 @@ func doUpdate(url string) error {
 		WithTitle("Downloading update").
 		Start()
 
-	return selfupdate.Apply(io.TeeReader(resp.Body, &progressWriter{bar}), selfupdate.Options{})
+	// A nil Checksum disables verification; selfupdate defaults to SHA-256 otherwise.
+	return selfupdate.Apply(io.TeeReader(resp.Body, &progressWriter{bar}), selfupdate.Options{Checksum: checksum})
 }
 
 type progressWriter struct {
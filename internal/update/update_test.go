@@ -0,0 +1,239 @@
+package update
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func withReleaseServer(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	orig := releasesURL
+	releasesURL = server.URL
+	t.Cleanup(func() { releasesURL = orig })
+}
+
+func TestCheck(t *testing.T) {
+	withReleaseServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]Release{
+			{TagName: "v1.2.0", Body: "Release notes"},
+		})
+	})
+
+	tests := []struct {
+		current string
+		want    bool
+	}{
+		{"1.1.0", true},
+		{"1.2.0", false},
+		{"v1.2.0", false},
+		{"1.3.0", false},
+	}
+
+	for _, tt := range tests {
+		release, hasUpdate, err := Check(tt.current, ChannelStable, "")
+		if err != nil {
+			t.Fatalf("Check(%q) failed: %v", tt.current, err)
+		}
+		if hasUpdate != tt.want {
+			t.Errorf("Check(%q) hasUpdate = %v, want %v", tt.current, hasUpdate, tt.want)
+		}
+		if release.TagName != "v1.2.0" || release.Body != "Release notes" {
+			t.Errorf("Check(%q) unexpected release: %+v", tt.current, release)
+		}
+	}
+}
+
+func TestCheckNoReleases(t *testing.T) {
+	withReleaseServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	_, hasUpdate, err := Check("1.0.0", ChannelStable, "")
+	if err != nil || hasUpdate {
+		t.Errorf("expected no update and no error, got %v, %v", hasUpdate, err)
+	}
+}
+
+func TestCheckDevBuild(t *testing.T) {
+	if _, _, err := Check("dev", ChannelStable, ""); !errors.Is(err, ErrDevBuild) {
+		t.Errorf("expected ErrDevBuild, got %v", err)
+	}
+}
+
+func TestCheckChannels(t *testing.T) {
+	withReleaseServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]Release{
+			{TagName: "v1.4.0", Draft: true},
+			{TagName: "v1.3.0-rc.1", Prerelease: true},
+			{TagName: "nightly", Prerelease: true},
+			{TagName: "v1.2.0"},
+			{TagName: "v1.1.0"},
+		})
+	})
+
+	tests := []struct {
+		channel Channel
+		current string
+		wantTag string
+		want    bool
+	}{
+		{ChannelStable, "1.1.0", "v1.2.0", true},
+		{ChannelStable, "1.2.0", "v1.2.0", false},
+		{ChannelBeta, "1.2.0", "v1.3.0-rc.1", true},
+		{ChannelBeta, "1.3.0-rc.1", "v1.3.0-rc.1", false},
+	}
+
+	for _, tt := range tests {
+		release, hasUpdate, err := Check(tt.current, tt.channel, "")
+		if err != nil {
+			t.Fatalf("Check(%q, %s) failed: %v", tt.current, tt.channel, err)
+		}
+		if release.TagName != tt.wantTag || hasUpdate != tt.want {
+			t.Errorf("Check(%q, %s) = %s, %v; want %s, %v", tt.current, tt.channel, release.TagName, hasUpdate, tt.wantTag, tt.want)
+		}
+	}
+}
+
+func TestCheckToken(t *testing.T) {
+	var gotAuth string
+	withReleaseServer(t, func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		_ = json.NewEncoder(w).Encode([]Release{})
+	})
+
+	if _, _, err := Check("1.0.0", ChannelStable, "secret"); err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("expected Authorization header with token, got %q", gotAuth)
+	}
+
+	if _, _, err := Check("1.0.0", ChannelStable, ""); err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if gotAuth != "" {
+		t.Errorf("expected no Authorization header without a token, got %q", gotAuth)
+	}
+}
+
+func TestCheckRateLimited(t *testing.T) {
+	withReleaseServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	if _, _, err := Check("1.0.0", ChannelStable, ""); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+}
+
+func TestParseChannel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Channel
+		wantErr bool
+	}{
+		{"", ChannelStable, false},
+		{"stable", ChannelStable, false},
+		{"beta", ChannelBeta, false},
+		{"nightly", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseChannel(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseChannel(%q) = %q, %v; want %q, wantErr %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFindAsset(t *testing.T) {
+	release := Release{Assets: []Asset{
+		{Name: "repoman-linux-amd64", BrowserDownloadURL: "https://example.com/linux"},
+		{Name: "repoman-windows-amd64.exe", BrowserDownloadURL: "https://example.com/windows"},
+	}}
+
+	if asset, err := findAsset(release, "linux", "amd64"); err != nil || asset.BrowserDownloadURL != "https://example.com/linux" {
+		t.Errorf("findAsset(linux) = %+v, %v", asset, err)
+	}
+	if asset, err := findAsset(release, "windows", "amd64"); err != nil || asset.BrowserDownloadURL != "https://example.com/windows" {
+		t.Errorf("findAsset(windows) = %+v, %v", asset, err)
+	}
+	if _, err := findAsset(release, "darwin", "arm64"); err == nil {
+		t.Error("expected error for missing asset")
+	}
+}
+
+func TestParseChecksum(t *testing.T) {
+	sum := sha256.Sum256([]byte("binary"))
+	hexSum := hex.EncodeToString(sum[:])
+	other := strings.Repeat("0", 64)
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"bare hash", hexSum + "\n", false},
+		{"sha256sum format", hexSum + "  repoman-linux-amd64\n", false},
+		{"binary mode marker", hexSum + " *repoman-linux-amd64\n", false},
+		{"checksums list", other + "  repoman-darwin-arm64\n" + hexSum + "  repoman-linux-amd64\n", false},
+		{"not listed", other + "  repoman-darwin-arm64\n", true},
+		{"malformed hash", "xyz  repoman-linux-amd64\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChecksum([]byte(tt.data), "repoman-linux-amd64")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %x", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseChecksum failed: %v", err)
+			}
+			if !bytes.Equal(got, sum[:]) {
+				t.Errorf("got %x, want %x", got, sum)
+			}
+		})
+	}
+}
+
+func TestFetchChecksum(t *testing.T) {
+	sum := sha256.Sum256([]byte("binary"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(hex.EncodeToString(sum[:]) + "  repoman-linux-amd64\n"))
+	}))
+	defer server.Close()
+
+	release := Release{Assets: []Asset{
+		{Name: "repoman-linux-amd64", BrowserDownloadURL: server.URL + "/bin"},
+		{Name: "checksums.txt", BrowserDownloadURL: server.URL + "/checksums.txt"},
+	}}
+	got, err := fetchChecksum(release, "repoman-linux-amd64")
+	if err != nil {
+		t.Fatalf("fetchChecksum failed: %v", err)
+	}
+	if !bytes.Equal(got, sum[:]) {
+		t.Errorf("got %x, want %x", got, sum)
+	}
+
+	// No checksum assets published: nothing to verify against.
+	got, err = fetchChecksum(Release{Assets: release.Assets[:1]}, "repoman-linux-amd64")
+	if err != nil || got != nil {
+		t.Errorf("expected nil checksum and no error, got %x, %v", got, err)
+	}
+}
@@ -0,0 +1,110 @@
+package update
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed semantic version (https://semver.org). Build metadata is ignored.
+type semver struct {
+	prerelease []string
+	major      int
+	minor      int
+	patch      int
+}
+
+// parseVersion parses a version string such as "1.2.3", "v1.2.3", or "v1.3.0-rc.1".
+// A leading "v" is optional, and missing minor/patch numbers are treated as zero.
+func parseVersion(s string) (semver, error) {
+	var v semver
+	orig := s
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		if s[i+1:] == "" {
+			return v, fmt.Errorf("invalid version %q", orig)
+		}
+		v.prerelease = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, fmt.Errorf("invalid version %q", orig)
+	}
+	nums := []*int{&v.major, &v.minor, &v.patch}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version %q", orig)
+		}
+		*nums[i] = n
+	}
+	return v, nil
+}
+
+// compare returns -1, 0, or +1 depending on whether v is older than, the same as, or
+// newer than other, following semver precedence rules.
+func (v semver) compare(other semver) int {
+	for _, pair := range [][2]int{{v.major, other.major}, {v.minor, other.minor}, {v.patch, other.patch}} {
+		if pair[0] != pair[1] {
+			return cmpInt(pair[0], pair[1])
+		}
+	}
+
+	// A pre-release version has lower precedence than the associated normal version.
+	switch {
+	case len(v.prerelease) == 0 && len(other.prerelease) == 0:
+		return 0
+	case len(v.prerelease) == 0:
+		return 1
+	case len(other.prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.prerelease) && i < len(other.prerelease); i++ {
+		a, b := v.prerelease[i], other.prerelease[i]
+		if a == b {
+			continue
+		}
+		aNum, aErr := strconv.Atoi(a)
+		bNum, bErr := strconv.Atoi(b)
+		switch {
+		case aErr == nil && bErr == nil:
+			return cmpInt(aNum, bNum)
+		case aErr == nil:
+			return -1 // numeric identifiers sort before alphanumeric ones
+		case bErr == nil:
+			return 1
+		default:
+			return strings.Compare(a, b)
+		}
+	}
+	return cmpInt(len(v.prerelease), len(other.prerelease))
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// isNewer reports whether the remote version is strictly newer than the current one.
+func isNewer(remote, current string) (bool, error) {
+	r, err := parseVersion(remote)
+	if err != nil {
+		return false, err
+	}
+	c, err := parseVersion(current)
+	if err != nil {
+		return false, err
+	}
+	return r.compare(c) > 0, nil
+}
@@ -0,0 +1,44 @@
+package update
+
+import "testing"
+
+func TestIsNewer(t *testing.T) {
+	tests := []struct {
+		remote  string
+		current string
+		want    bool
+	}{
+		{"v1.2.0", "1.2.0", false},
+		{"1.2.0", "v1.2.0", false},
+		{"v1.2.1", "1.2.0", true},
+		{"v1.10.0", "1.9.0", true},
+		{"v2.0.0", "1.99.99", true},
+		{"v1.1.0", "1.2.0", false}, // older remote
+		{"v1.2.0", "1.2.0-rc.1", true},
+		{"v1.2.0-rc.1", "1.2.0", false},
+		{"v1.2.0-rc.2", "1.2.0-rc.1", true},
+		{"v1.2.0-rc.10", "1.2.0-rc.9", true},
+		{"v1.2.0-beta", "1.2.0-alpha", true},
+		{"v1.2.0+build.5", "1.2.0", false},
+		{"v1.3", "1.2.9", true},
+	}
+
+	for _, tt := range tests {
+		got, err := isNewer(tt.remote, tt.current)
+		if err != nil {
+			t.Errorf("isNewer(%q, %q) unexpected error: %v", tt.remote, tt.current, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("isNewer(%q, %q) = %v, want %v", tt.remote, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestParseVersionInvalid(t *testing.T) {
+	for _, s := range []string{"dev", "", "v1.2.3.4", "1.x.0", "1.2.3-"} {
+		if _, err := parseVersion(s); err == nil {
+			t.Errorf("parseVersion(%q) expected error", s)
+		}
+	}
+}
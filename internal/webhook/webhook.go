@@ -0,0 +1,127 @@
+// Package webhook posts summaries of repoman runs to an external HTTP endpoint.
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultAttempts = 3
+	defaultBackoff  = time.Second
+	requestTimeout  = 5 * time.Second
+)
+
+// RepoResult describes the outcome for a single repository.
+type RepoResult struct {
+	Name        string `json:"name"`
+	OK          bool   `json:"ok"`
+	Error       string `json:"error,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+	Status      string `json:"status,omitempty"`
+	SyncState   string `json:"sync_state,omitempty"`
+	CommitCount int    `json:"commit_count,omitempty"`
+}
+
+// Event is the JSON payload posted to the webhook at the end of a run.
+type Event struct {
+	Timestamp      time.Time    `json:"timestamp"`
+	Type           string       `json:"event"`
+	CourseID       string       `json:"course_id"`
+	CourseName     string       `json:"course_name"`
+	AssignmentID   string       `json:"assignment_id"`
+	AssignmentName string       `json:"assignment_name"`
+	Repos          []RepoResult `json:"repos"`
+	Total          int          `json:"total"`
+	Succeeded      int          `json:"succeeded"`
+	Failed         int          `json:"failed"`
+}
+
+// Poster delivers events to a webhook URL in the background.
+type Poster struct {
+	httpClient *http.Client
+	done       chan struct{}
+	err        error
+	url        string
+	attempts   int
+	backoff    time.Duration
+}
+
+// NewPoster creates a Poster for the given webhook URL.
+func NewPoster(url string) *Poster {
+	return &Poster{
+		url:        url,
+		attempts:   defaultAttempts,
+		backoff:    defaultBackoff,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Post fills in the event's totals and sends it in the background, retrying failed
+// deliveries. It returns immediately; use Wait to collect the outcome.
+// Post must be called at most once per Poster.
+func (p *Poster) Post(event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	event.Total = len(event.Repos)
+	event.Succeeded, event.Failed = 0, 0
+	for _, r := range event.Repos {
+		if r.OK {
+			event.Succeeded++
+		} else {
+			event.Failed++
+		}
+	}
+
+	p.done = make(chan struct{})
+	go func() {
+		defer close(p.done)
+		p.err = p.deliver(event)
+	}()
+}
+
+// Wait blocks until the event posted with Post has been delivered or has failed, or until
+// the timeout elapses. It returns the delivery error, if any.
+func (p *Poster) Wait(timeout time.Duration) error {
+	if p.done == nil {
+		return errors.New("no webhook event was posted")
+	}
+	select {
+	case <-p.done:
+		return p.err
+	case <-time.After(timeout):
+		return errors.New("timed out waiting for webhook delivery")
+	}
+}
+
+func (p *Poster) deliver(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= p.attempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(p.backoff * time.Duration(attempt-1))
+		}
+
+		resp, err := p.httpClient.Post(p.url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return fmt.Errorf("webhook delivery failed after %d attempts: %w", p.attempts, lastErr)
+}
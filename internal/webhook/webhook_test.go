@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPost(t *testing.T) {
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected Content-Type application/json, got %s", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	poster := NewPoster(server.URL)
+	poster.Post(Event{
+		Type:           "sync",
+		CourseID:       "cs101",
+		CourseName:     "Intro to CS",
+		AssignmentID:   "lab1",
+		AssignmentName: "Lab 1",
+		Repos: []RepoResult{
+			{Name: "alice", OK: true},
+			{Name: "bob", OK: false, Error: "git clone failed"},
+		},
+	})
+
+	if err := poster.Wait(5 * time.Second); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+
+	for key, want := range map[string]any{
+		"event":           "sync",
+		"course_id":       "cs101",
+		"assignment_name": "Lab 1",
+		"total":           float64(2),
+		"succeeded":       float64(1),
+		"failed":          float64(1),
+	} {
+		if received[key] != want {
+			t.Errorf("expected %s = %v, got %v", key, want, received[key])
+		}
+	}
+	if _, ok := received["timestamp"].(string); !ok {
+		t.Errorf("expected timestamp string, got %v", received["timestamp"])
+	}
+
+	repos, ok := received["repos"].([]any)
+	if !ok || len(repos) != 2 {
+		t.Fatalf("expected 2 repos, got %v", received["repos"])
+	}
+	bob, _ := repos[1].(map[string]any)
+	if bob["name"] != "bob" || bob["ok"] != false || bob["error"] != "git clone failed" {
+		t.Errorf("unexpected repo payload: %v", bob)
+	}
+}
+
+func TestPostRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	poster := NewPoster(server.URL)
+	poster.backoff = time.Millisecond
+	poster.Post(Event{Type: "status"})
+
+	if err := poster.Wait(5 * time.Second); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected 2 attempts, got %d", got)
+	}
+}
+
+func TestPostFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	poster := NewPoster(server.URL)
+	poster.backoff = time.Millisecond
+	poster.Post(Event{Type: "sync"})
+
+	if err := poster.Wait(5 * time.Second); err == nil {
+		t.Error("expected an error after all attempts failed")
+	}
+}